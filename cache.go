@@ -0,0 +1,130 @@
+package pathfind
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/bolom009/pathfind/graphs"
+)
+
+// PathCacheStats represent counters of path cache
+// Invalidations counts cached paths which were dropped because graph was changed after their search
+type PathCacheStats struct {
+	Hits          uint64
+	Misses        uint64
+	Invalidations uint64
+	Size          int
+}
+
+// pathCache is LRU cache of found paths, it's safe for concurrent use
+// Paths are checked by graphs.ChangeTracker on read, so obstacle updates don't have to walk the cache
+type pathCache[Node comparable] struct {
+	mu      sync.Mutex
+	size    int
+	entries map[pathKey]*list.Element
+	order   *list.List
+	stats   PathCacheStats
+}
+
+// pathKey is query of path, points are keyed by graph HashIndex which is exact for built-in graphs (1e-6 units),
+// so cache hits only repeated queries of the same points. Search modes of query are part of key
+type pathKey struct {
+	graphID         int
+	start           int64
+	dest            int64
+	agentRadius     float32
+	jumpPointSearch bool
+}
+
+func newPathKey[Node comparable](graphID int, g graphs.NavGraph[Node], start, dest Node, navOpts *graphs.NavOpts) pathKey {
+	return pathKey{
+		graphID:         graphID,
+		start:           g.HashIndex(start),
+		dest:            g.HashIndex(dest),
+		agentRadius:     navOpts.AgentRadius,
+		jumpPointSearch: navOpts.JumpPointSearch,
+	}
+}
+
+type cacheEntry[Node comparable] struct {
+	key     pathKey
+	nodes   []Node
+	version uint64
+}
+
+func newPathCache[Node comparable](size int) *pathCache[Node] {
+	return &pathCache[Node]{
+		size:    size,
+		entries: make(map[pathKey]*list.Element, size),
+		order:   list.New(),
+	}
+}
+
+// cacheable checks if query result depends only on graph, paths of queries with obstacles, area options
+// or own searcher aren't cached
+func cacheable(navOpts *graphs.NavOpts) bool {
+	return len(navOpts.Obstacles) == 0 && !navOpts.HasAreaFilter() && !navOpts.HasAreaCosts() && navOpts.Searcher == nil
+}
+
+// get return cached path if graph wasn't changed in its area after search
+func (c *pathCache[Node]) get(g graphs.NavGraph[Node], key pathKey) ([]Node, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+
+	entry := el.Value.(*cacheEntry[Node])
+	if tracker, ok := g.(graphs.ChangeTracker[Node]); ok && tracker.PathChanged(entry.nodes, entry.version) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		c.stats.Invalidations++
+		c.stats.Misses++
+		return nil, false
+	}
+
+	c.order.MoveToFront(el)
+	c.stats.Hits++
+	return entry.nodes, true
+}
+
+// put add path found at graph version, the least recently used path is evicted if cache is full
+func (c *pathCache[Node]) put(key pathKey, nodes []Node, version uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*cacheEntry[Node])
+		entry.nodes, entry.version = nodes, version
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry[Node]{key: key, nodes: nodes, version: version})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry[Node]).key)
+	}
+}
+
+// clear drop all cached paths, stats are kept
+func (c *pathCache[Node]) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.entries)
+	c.order.Init()
+}
+
+func (c *pathCache[Node]) statistics() PathCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Size = c.order.Len()
+	return stats
+}
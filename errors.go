@@ -0,0 +1,18 @@
+package pathfind
+
+import "errors"
+
+var (
+	// ErrUnknownGraph is returned when graph ID is out of pathfinder graphs
+	ErrUnknownGraph = errors.New("unknown graph")
+	// ErrNotInitialized is returned when pathfinder is used before successful Initialize
+	ErrNotInitialized = errors.New("pathfinder is not initialized")
+	// ErrStartOutsideMesh is returned when path isn't found and start point is out of graph area
+	ErrStartOutsideMesh = errors.New("start is outside of mesh")
+	// ErrDestOutsideMesh is returned when path isn't found and dest point is out of graph area
+	ErrDestOutsideMesh = errors.New("dest is outside of mesh")
+	// ErrDestUnreachable is returned when both points are in graph area, but there is no path between them
+	ErrDestUnreachable = errors.New("dest is unreachable")
+	// ErrPathInProgress is returned by result of time-sliced query which search isn't finished
+	ErrPathInProgress = errors.New("path search is in progress")
+)
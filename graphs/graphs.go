@@ -3,6 +3,7 @@ package graphs
 import (
 	"context"
 	"maps"
+	"slices"
 
 	"github.com/bolom009/pathfind/mesh"
 	"github.com/bolom009/pathfind/obstacles"
@@ -10,13 +11,54 @@ import (
 
 // NavOpts contains optional objects for aggregation graph
 // Obstacles parameter will exclude nodes & edges of generated graph by obstacles polygon
-// AgentRadius parameter will take into account the path search by agent radius
+// AgentRadius parameter will take into account the path search by agent radius (obstacles are inflated by it)
+// IncludeAreas allows only listed area types if it isn't empty, ExcludeAreas forbids listed area types
+// AreaCosts overrides cost multipliers of graph area types
+// JumpPointSearch enables Jump Point Search for query if graph supports it (uniform-cost grid)
+// Searcher is search algorithm of pathfinder (pathfind.Searcher of graph node type) which is used instead of
+// graph search modes and A*
 type NavOpts struct {
-	Obstacles   []obstacles.Obstacle
-	AgentRadius float32
+	Obstacles       []obstacles.Obstacle
+	AgentRadius     float32
+	IncludeAreas    []mesh.AreaType
+	ExcludeAreas    []mesh.AreaType
+	AreaCosts       mesh.AreaCosts
+	JumpPointSearch bool
+	Searcher        any
 }
 
+// HasAreaFilter checks if some area types are forbidden for query
+func (o *NavOpts) HasAreaFilter() bool {
+	return o != nil && (len(o.IncludeAreas) > 0 || len(o.ExcludeAreas) > 0)
+}
+
+// AreaAllowed checks if area type is passable for query
+func (o *NavOpts) AreaAllowed(area mesh.AreaType) bool {
+	if o == nil {
+		return true
+	}
+
+	if len(o.IncludeAreas) > 0 && !slices.Contains(o.IncludeAreas, area) {
+		return false
+	}
+
+	return !slices.Contains(o.ExcludeAreas, area)
+}
+
+// HasAreaCosts checks if query overrides costs of areas
+func (o *NavOpts) HasAreaCosts() bool {
+	return o != nil && len(o.AreaCosts) > 0
+}
+
+// ProgressFunc is called during graph generation, progress is in range 0..1
+type ProgressFunc func(progress float32)
+
 // NavGraph is represented an interface for graph types
+// Generate stops with context error if ctx is canceled, graph data stays as it was before Generate,
+// nil ctx is treated as context.Background().
+// Generate must be done before graph is shared between goroutines. After that query methods
+// are safe for concurrent use, also with AddObstacles and RemoveObstacles in progress:
+// updates are serialized and queries read last published graph data
 type NavGraph[Node comparable] interface {
 	Generate(ctx context.Context) error
 	AggregationGraph(Node, Node, *NavOpts) Graph[Node]
@@ -30,6 +72,62 @@ type NavGraph[Node comparable] interface {
 	HashIndex(Node) int64
 }
 
+// PathSearcher is an optional interface for graph types which search path by themselves
+// without aggregation graph. SearchPath returns false if graph search mode is disabled,
+// in this case path is searched by A* over AggregationGraph
+type PathSearcher[Node comparable] interface {
+	SearchPath(start, dest Node, opts *NavOpts) ([]Node, bool)
+}
+
+// PathDescriber is an optional interface for graph types which could describe found path by their areas
+// Corridor returns indexes of graph cells (triangles, squares) which path goes through.
+// SnapPoint returns point of graph area which is used by search instead of point out of area
+// and false if point isn't snapped
+type PathDescriber[Node comparable] interface {
+	Corridor(path []Node, opts *NavOpts) []int32
+	SnapPoint(point Node, opts *NavOpts) (Node, bool)
+}
+
+// Estimator is an optional interface for graph types which cost isn't bounded by distance (cheap areas)
+// Estimate returns lower bound of path cost between nodes, it's used as A* heuristic instead of Cost
+type Estimator[Node comparable] interface {
+	Estimate(a, b Node) float32
+}
+
+// QueryCoster is an optional interface for graph types which costs depend on query (area costs of NavOpts)
+// QueryCosts returns cost and A* heuristic functions which are used instead of Cost and Estimate
+type QueryCoster[Node comparable] interface {
+	QueryCosts(opts *NavOpts) (cost, estimate func(a, b Node) float32)
+}
+
+// SightChecker is an optional interface for graph types which could check straight moves over walkable area of query
+// LineOfSight returns check which is true if segment goes only through walkable area (holes, extra obstacles,
+// obstacles of opts and agent radius are taken into account), it's used by any-angle search instead of IsRaycastHit
+type SightChecker[Node comparable] interface {
+	LineOfSight(opts *NavOpts) func(a, b Node) bool
+}
+
+// PathLink is off-mesh link which path goes through, Segment is index of path segment (Nodes[Segment] -> Nodes[Segment+1])
+type PathLink struct {
+	Segment int
+	ID      uint32
+	Meta    any
+}
+
+// PathLinker is an optional interface for graph types which have off-mesh links (jumps, ladders, teleporters)
+// PathLinks returns links which path goes through in order of path
+type PathLinker[Node comparable] interface {
+	PathLinks(path []Node, opts *NavOpts) []PathLink
+}
+
+// ChangeTracker is an optional interface for graph types which could be changed after Generate (extra obstacles)
+// Version returns count of changes, it must be read before search. PathChanged checks if path found at version
+// could be different after later changes, graph could report changed path even if it's still the shortest
+type ChangeTracker[Node comparable] interface {
+	Version() uint64
+	PathChanged(path []Node, version uint64) bool
+}
+
 // Graph is represented by an adjacency list.
 type Graph[Node comparable] map[Node][]Node
 
@@ -93,10 +191,22 @@ func (g Graph[Node]) DeleteNeighbour(node, neighbour Node) Graph[Node] {
 }
 
 // Copy return copy of graph
+// Neighbours slices are shared with origin graph, see Clip
 func (g Graph[Node]) Copy() Graph[Node] {
 	return maps.Clone(g)
 }
 
+// Clip removes spare capacity of neighbours slices,
+// so linking nodes in copy of graph allocates new slices instead of writing to shared ones.
+// Graph which is read by several goroutines must be clipped
+func (g Graph[Node]) Clip() Graph[Node] {
+	for node, neighbours := range g {
+		g[node] = slices.Clip(neighbours)
+	}
+
+	return g
+}
+
 // Neighbours returns the neighbour nodes of node n in the graph.
 // This method makes graph[Node] implement the astar.Graph[Node] interface.
 func (g Graph[Node]) Neighbours(n Node) []Node {
@@ -0,0 +1,56 @@
+package grid
+
+import (
+	"github.com/bolom009/pathfind/graphs"
+	"github.com/bolom009/pathfind/mesh"
+)
+
+// areaIndex is visible squares of each area type and squares which share sides on borders of areas
+// It's built by Generate, so area filters of query check only squares of forbidden areas
+type areaIndex struct {
+	squares map[mesh.AreaType][]int32
+	borders map[Edge][]int32
+}
+
+func newAreaIndex(visSquares []Square) *areaIndex {
+	idx := &areaIndex{squares: make(map[mesh.AreaType][]int32)}
+	for i, square := range visSquares {
+		idx.squares[square.Area] = append(idx.squares[square.Area], int32(i))
+	}
+
+	if len(idx.squares) < 2 {
+		return idx
+	}
+
+	// sides of neighbour squares have the same points order, diagonals aren't shared
+	sides := make(map[Edge][]int32, 2*len(visSquares))
+	for i, square := range visSquares {
+		edges := square.graphEdges()
+		for _, edge := range edges[:4] {
+			sides[edge] = append(sides[edge], int32(i))
+		}
+	}
+
+	idx.borders = make(map[Edge][]int32)
+	for edge, squares := range sides {
+		for _, square := range squares[1:] {
+			if visSquares[square].Area != visSquares[squares[0]].Area {
+				idx.borders[edge] = squares
+				break
+			}
+		}
+	}
+
+	return idx
+}
+
+// sharedAllowed checks if edge is side of square of allowed area which isn't blocked
+func (idx *areaIndex) sharedAllowed(edge Edge, visSquares []Square, state *snapshot, navOpts *graphs.NavOpts) bool {
+	for _, square := range idx.borders[edge] {
+		if !state.isBlocked(int(square)) && navOpts.AreaAllowed(visSquares[square].Area) {
+			return true
+		}
+	}
+
+	return false
+}
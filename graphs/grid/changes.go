@@ -0,0 +1,61 @@
+package grid
+
+import (
+	"github.com/bolom009/geom"
+	"github.com/bolom009/pathfind/graphs/internal"
+)
+
+// bounds is box of squares blocked by change
+type bounds struct {
+	min, max geom.Vector2
+}
+
+// Version return count of changes of grid data (Generate and obstacles updates)
+// This method makes Grid implement the graphs.ChangeTracker interface.
+func (g *Grid) Version() uint64 {
+	return g.changes.Version()
+}
+
+// PathChanged checks if path which was found at version could be changed by later updates
+// Path is changed by squares blocked later which bounds it crosses and by any removed obstacle,
+// because new shorter path could go anywhere.
+// This method makes Grid implement the graphs.ChangeTracker interface.
+func (g *Grid) PathChanged(path []geom.Vector2, version uint64) bool {
+	return g.changes.PathChanged(version, func(b bounds) bool {
+		return b.crossedBy(path)
+	})
+}
+
+// recordSquares publish change of blocked squares bounds, paths which don't cross them stay the shortest,
+// because blocked squares only remove their vertices and edges
+func (g *Grid) recordSquares(squares []int32) {
+	box := bounds{min: g.visSquares[squares[0]].A, max: g.visSquares[squares[0]].C}
+	for _, square := range squares {
+		a, b := g.visSquares[square].A, g.visSquares[square].C
+		box.min.X, box.min.Y = min(box.min.X, a.X, b.X), min(box.min.Y, a.Y, b.Y)
+		box.max.X, box.max.Y = max(box.max.X, a.X, b.X), max(box.max.Y, a.Y, b.Y)
+	}
+
+	g.changes.Record(internal.Change[bounds]{Bounds: box})
+}
+
+// crossedBy checks if some segment of path touches bounds
+func (c bounds) crossedBy(path []geom.Vector2) bool {
+	if len(path) == 1 {
+		return c.crossesSegment(path[0], path[0])
+	}
+
+	for i := 0; i < len(path)-1; i++ {
+		if c.crossesSegment(path[i], path[i+1]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// crossesSegment checks if segment touches bounds
+func (c bounds) crossesSegment(a, b geom.Vector2) bool {
+	_, _, ok := clipSegment(a, b, c.min, c.max)
+	return ok
+}
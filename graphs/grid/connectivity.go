@@ -0,0 +1,70 @@
+package grid
+
+import (
+	"math"
+
+	"github.com/bolom009/geom"
+)
+
+// Connectivity is rule of moves between vertices of squares
+type Connectivity uint8
+
+const (
+	// Connectivity8 links sides and diagonals of visible squares, path could pass vertex between two diagonal blocked squares
+	Connectivity8 Connectivity = iota
+	// Connectivity4 links only sides of visible squares, vertices between two diagonal blocked squares aren't passed
+	Connectivity4
+	// Connectivity8NoCornerCutting links sides and diagonals of visible squares, but vertices between two diagonal
+	// blocked squares aren't passed, so path doesn't slip between touching walls
+	Connectivity8NoCornerCutting
+)
+
+const (
+	// octileSlack is the biggest excess of octile distance over straight line inside square of size 1
+	octileSlack = 0.0899
+	// manhattanSlack is the biggest excess of manhattan distance over straight line inside square of size 1
+	manhattanSlack = 2 - math.Sqrt2
+)
+
+// diagonals checks if diagonals of squares are linked
+func (c Connectivity) diagonals() bool {
+	return c != Connectivity4
+}
+
+// cornerCutting checks if path could pass vertex between two diagonal blocked squares
+func (c Connectivity) cornerCutting() bool {
+	return c == Connectivity8
+}
+
+// isPinch checks if only two diagonal squares around vertex are visible, path goes through such vertex between blocked squares
+func isPinch(c cell, visible func(cell) bool) bool {
+	var (
+		bottomLeft  = visible(cell{x: c.x - 1, y: c.y - 1})
+		bottomRight = visible(cell{x: c.x, y: c.y - 1})
+		topRight    = visible(c)
+		topLeft     = visible(cell{x: c.x - 1, y: c.y})
+	)
+
+	return (bottomLeft && topRight && !bottomRight && !topLeft) || (bottomRight && topLeft && !bottomLeft && !topRight)
+}
+
+// gridEstimate return length of the shortest moves along sides (and diagonals) of squares between points,
+// it's decreased by slack of straight links of start and dest with corners, so it's never bigger than length of path
+func (g *Grid) gridEstimate(a, b geom.Vector2) float32 {
+	var (
+		dx       = float64(abs32(a.X - b.X))
+		dy       = float64(abs32(a.Y - b.Y))
+		straight = math.Hypot(dx, dy)
+		moves    = max(dx, dy) + (math.Sqrt2-1)*min(dx, dy) - octileSlack*float64(g.squareSize)
+	)
+
+	if !g.connectivity.diagonals() {
+		moves = dx + dy - manhattanSlack*float64(g.squareSize)
+	}
+
+	return float32(max(straight, moves))
+}
+
+func abs32(v float32) float32 {
+	return max(v, -v)
+}
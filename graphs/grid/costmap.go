@@ -0,0 +1,268 @@
+package grid
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"slices"
+
+	"github.com/bolom009/geom"
+	"github.com/bolom009/pathfind/mesh"
+)
+
+// costMap is dense row-major index of traversal costs of cells of square size, cell with non-positive cost is blocked
+// Cost of segment is split by cells which it goes through, segment along common side of cells costs as the cheapest cell
+type costMap struct {
+	origin   geom.Vector2
+	cellSize float32
+	width    int32
+	height   int32
+	costs    []float32
+	minCost  float32
+	weighted bool
+}
+
+// NewGridFromCosts create grid of squares from 2D array of traversal costs, costs[y][x] is multiplier of cost of square
+// with A vertex at offset (WithOffset) + (x, y) * squareSize. Cells with non-positive, NaN or infinite cost are blocked,
+// missing cells of shorter rows are blocked too. Cell costs are multiplied by costs of areas (WithAreas)
+func NewGridFromCosts(costs [][]float32, squareSize float32, options ...option) *Grid {
+	g := NewGrid(nil, nil, squareSize, options...)
+
+	m := &costMap{origin: g.offset, cellSize: squareSize, height: int32(len(costs)), minCost: math.MaxFloat32}
+	for _, row := range costs {
+		m.width = max(m.width, int32(len(row)))
+	}
+
+	m.costs = make([]float32, m.width*m.height)
+	for y, row := range costs {
+		for x, cost := range row {
+			if cost <= 0 || math.IsNaN(float64(cost)) || math.IsInf(float64(cost), 0) {
+				continue
+			}
+
+			m.costs[int32(y)*m.width+int32(x)] = cost
+			m.minCost = min(m.minCost, cost)
+			m.weighted = m.weighted || cost != 1
+		}
+	}
+
+	if m.minCost == math.MaxFloat32 {
+		m.minCost = 1
+	}
+
+	if m.width > 0 && m.height > 0 {
+		g.polygon = m.polygon()
+	}
+
+	g.costMap = m
+	return g
+}
+
+// NewGridFromImage create grid of squares from pixels of image, pixel (x, y) is square with A vertex at
+// offset + (x, y) * squareSize. Pixels are converted to gray and costOf maps gray value to cost (GrayCost if nil)
+func NewGridFromImage(img image.Image, squareSize float32, costOf func(gray uint8) float32, options ...option) *Grid {
+	if costOf == nil {
+		costOf = GrayCost
+	}
+
+	var (
+		bounds = img.Bounds()
+		costs  = make([][]float32, bounds.Dy())
+	)
+
+	for y := range costs {
+		costs[y] = make([]float32, bounds.Dx())
+		for x := range costs[y] {
+			gray := color.GrayModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray)
+			costs[y][x] = costOf(gray.Y)
+		}
+	}
+
+	return NewGridFromCosts(costs, squareSize, options...)
+}
+
+// NewGridFromPNG create grid of squares from PNG image (usually grayscale cost map), see NewGridFromImage
+func NewGridFromPNG(r io.Reader, squareSize float32, costOf func(gray uint8) float32, options ...option) (*Grid, error) {
+	img, err := png.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewGridFromImage(img, squareSize, costOf, options...), nil
+}
+
+// GrayCost maps black pixel to blocked cell and lighter pixels to cheaper cells, white pixel costs 1
+func GrayCost(gray uint8) float32 {
+	if gray == 0 {
+		return 0
+	}
+
+	return 255 / float32(gray)
+}
+
+// generateSquares return all squares of cost map tagged by areas, squares of blocked cells aren't visible
+func (m *costMap) generateSquares(areas *mesh.Areas) ([]Square, []Square) {
+	var (
+		squares    = make([]Square, 0, len(m.costs))
+		visSquares = make([]Square, 0, len(m.costs))
+	)
+
+	for x := int32(0); x < m.width; x++ {
+		for y := int32(0); y < m.height; y++ {
+			var (
+				a        = m.origin.Add(geom.Vector2{X: float32(x) * m.cellSize, Y: float32(y) * m.cellSize})
+				c        = m.origin.Add(geom.Vector2{X: float32(x+1) * m.cellSize, Y: float32(y+1) * m.cellSize})
+				walkable = m.costs[y*m.width+x] > 0
+			)
+
+			square := Square{
+				A:        a,
+				B:        geom.Vector2{X: c.X, Y: a.Y},
+				C:        c,
+				D:        geom.Vector2{X: a.X, Y: c.Y},
+				Center:   a.Lerp(c, 0.5),
+				Area:     areas.AreaAt(a.Lerp(c, 0.5)),
+				isA:      walkable,
+				isB:      walkable,
+				isC:      walkable,
+				isD:      walkable,
+				isCenter: walkable,
+			}
+
+			squares = append(squares, square)
+			if walkable {
+				visSquares = append(visSquares, square)
+			}
+		}
+	}
+
+	return squares, visSquares
+}
+
+// edges return outline of map and sides of blocked cells which are shared with walkable cells
+func (m *costMap) edges() [][2]geom.Vector2 {
+	edges := outlineEdges(m.polygon())
+	for y := int32(0); y < m.height; y++ {
+		for x := int32(0); x < m.width; x++ {
+			if m.costs[y*m.width+x] > 0 {
+				continue
+			}
+
+			var (
+				a = m.origin.Add(geom.Vector2{X: float32(x) * m.cellSize, Y: float32(y) * m.cellSize})
+				c = m.origin.Add(geom.Vector2{X: float32(x+1) * m.cellSize, Y: float32(y+1) * m.cellSize})
+				b = geom.Vector2{X: c.X, Y: a.Y}
+				d = geom.Vector2{X: a.X, Y: c.Y}
+			)
+
+			if m.walkable(cell{x: x, y: y - 1}) {
+				edges = append(edges, [2]geom.Vector2{a, b})
+			}
+			if m.walkable(cell{x: x + 1, y: y}) {
+				edges = append(edges, [2]geom.Vector2{b, c})
+			}
+			if m.walkable(cell{x: x, y: y + 1}) {
+				edges = append(edges, [2]geom.Vector2{c, d})
+			}
+			if m.walkable(cell{x: x - 1, y: y}) {
+				edges = append(edges, [2]geom.Vector2{d, a})
+			}
+		}
+	}
+
+	return edges
+}
+
+// polygon return outline of map
+func (m *costMap) polygon() []geom.Vector2 {
+	size := geom.Vector2{X: float32(m.width) * m.cellSize, Y: float32(m.height) * m.cellSize}
+	return []geom.Vector2{m.origin, {X: m.origin.X + size.X, Y: m.origin.Y}, m.origin.Add(size), {X: m.origin.X, Y: m.origin.Y + size.Y}}
+}
+
+// walkable checks if cell is inside map and isn't blocked
+func (m *costMap) walkable(c cell) bool {
+	return c.x >= 0 && c.y >= 0 && c.x < m.width && c.y < m.height && m.costs[c.y*m.width+c.x] > 0
+}
+
+// containsPoint checks if point is inside or on side of walkable cell
+func (m *costMap) containsPoint(point geom.Vector2) bool {
+	_, ok := m.costAt(point)
+	return ok
+}
+
+// costAt return the lowest cost of walkable cells which contain point, point on side belongs to both cells
+func (m *costMap) costAt(point geom.Vector2) (float32, bool) {
+	var (
+		xs   = cellRange(float64((point.X - m.origin.X) / m.cellSize))
+		ys   = cellRange(float64((point.Y - m.origin.Y) / m.cellSize))
+		cost = float32(math.MaxFloat32)
+	)
+
+	for x := xs[0]; x <= xs[1]; x++ {
+		for y := ys[0]; y <= ys[1]; y++ {
+			if m.walkable(cell{x: x, y: y}) {
+				cost = min(cost, m.costs[y*m.width+x])
+			}
+		}
+	}
+
+	return cost, cost != math.MaxFloat32
+}
+
+// segmentCost return cost of segment, costFunc measures parts of segment inside one cell which are multiplied by cell cost
+// parts out of walkable cells cost as default cell
+func (m *costMap) segmentCost(p0, p1 geom.Vector2, costFunc func(a, b geom.Vector2) float32) float32 {
+	var (
+		from   = [2]float64{float64((p0.X - m.origin.X) / m.cellSize), float64((p0.Y - m.origin.Y) / m.cellSize)}
+		to     = [2]float64{float64((p1.X - m.origin.X) / m.cellSize), float64((p1.Y - m.origin.Y) / m.cellSize)}
+		params = []float32{0, 1}
+	)
+
+	// segment is split where it crosses lines of cells
+	for axis := range from {
+		if from[axis] == to[axis] {
+			continue
+		}
+
+		lo, hi := min(from[axis], to[axis]), max(from[axis], to[axis])
+		for line := math.Floor(lo) + 1; line < hi; line++ {
+			params = append(params, float32((line-from[axis])/(to[axis]-from[axis])))
+		}
+	}
+
+	slices.Sort(params)
+
+	cost := float32(0)
+	for i := 0; i < len(params)-1; i++ {
+		if params[i+1] <= params[i] {
+			continue
+		}
+
+		var (
+			a = p0.Lerp(p1, params[i])
+			b = p0.Lerp(p1, params[i+1])
+		)
+
+		multiplier, ok := m.costAt(a.Lerp(b, 0.5))
+		if !ok {
+			multiplier = 1
+		}
+
+		cost += costFunc(a, b) * multiplier
+	}
+
+	return cost
+}
+
+// cellRange return first and last cells which contain coordinate, coordinate close to line belongs to both cells
+func cellRange(v float64) [2]int32 {
+	const eps = 1e-4
+
+	if line := math.Round(v); math.Abs(v-line) < eps {
+		return [2]int32{int32(line) - 1, int32(line)}
+	}
+
+	return [2]int32{int32(math.Floor(v)), int32(math.Floor(v))}
+}
@@ -3,23 +3,65 @@ package grid
 import (
 	"context"
 	"math"
+	"sync"
+	"sync/atomic"
 
 	"github.com/bolom009/astar"
 	"github.com/bolom009/geom"
 	"github.com/bolom009/pathfind/graphs"
+	"github.com/bolom009/pathfind/graphs/internal"
 	"github.com/bolom009/pathfind/mesh"
 	"github.com/bolom009/pathfind/obstacles"
 )
 
+// Grid is navigation graph based on squares of polygon
+// Graph data which is changed by extra obstacles is immutable for queries: updates make changed copy and swap it atomically,
+// so queries don't wait for obstacle updates and updates are serialized by mutex
 type Grid struct {
 	polygon         []geom.Vector2
 	holes           [][]geom.Vector2
 	squares         []Square
 	visSquares      []Square
-	visibilityGraph graphs.Graph[geom.Vector2]
+	state           atomic.Pointer[snapshot]
+	mu              sync.Mutex
 	squareSize      float32
 	costFunc        astar.CostFunc[geom.Vector2]
+	estimateFunc    astar.CostFunc[geom.Vector2]
+	connectivity    Connectivity
+	progressFunc    graphs.ProgressFunc
 	offset          geom.Vector2
+	areaHoles       []*mesh.Hole
+	areaCosts       mesh.AreaCosts
+	areas           *mesh.Areas
+	areaIndex       *areaIndex
+	clusterSize     int32
+	jumpPointSearch bool
+	raycaster       *raycaster
+
+	// costMap is set for grid of cost array or image (NewGridFromCosts), it replaces polygon with holes
+	costMap *costMap
+
+	// obstacleRaycasts enables raycasts against extra obstacles which aren't viewable
+	obstacleRaycasts bool
+
+	// extra obstacles, lattice of visible squares without obstacles and visible squares blocked by each obstacle
+	obstaclePool    *internal.ObstaclePool
+	lattice         *lattice
+	obstacleSquares map[uint32][]int32
+
+	// changes is log of last updates which is checked by path caches
+	changes internal.Changes[bounds]
+}
+
+// snapshot is graph data which is read by queries, blocked is count of obstacles which block each visible square
+// Lattice and visibility graph don't contain blocked squares, raycast obstacles are extra obstacles which block raycasts
+type snapshot struct {
+	visibilityGraph  graphs.Graph[geom.Vector2]
+	lattice          *lattice
+	blocked          []uint16
+	hierarchy        *hierarchy
+	jumpPoints       *jumpPoints
+	raycastObstacles []*mesh.Hole
 }
 
 func NewGrid(polygon []geom.Vector2, holes [][]geom.Vector2, squareSize float32, options ...option) *Grid {
@@ -28,44 +70,197 @@ func NewGrid(polygon []geom.Vector2, holes [][]geom.Vector2, squareSize float32,
 		holes:           holes,
 		squareSize:      squareSize,
 		squares:         make([]Square, 0),
-		visibilityGraph: make(graphs.Graph[geom.Vector2]),
 		costFunc:        heuristicEvaluation,
+		obstaclePool:    internal.NewObstaclePool(30),
+		obstacleSquares: make(map[uint32][]int32),
 	}
 
 	for _, option := range options {
 		option(g)
 	}
 
+	if g.estimateFunc == nil {
+		g.estimateFunc = g.gridEstimate
+	}
+
+	g.areas = mesh.NewAreas(g.areaHoles, g.areaCosts)
+	g.state.Store(&snapshot{visibilityGraph: make(graphs.Graph[geom.Vector2])})
 	return g
 }
 
-func (g *Grid) Generate(_ context.Context) error {
-	g.squares, g.visSquares = g.generateSquares()
-	g.visibilityGraph = g.generateGraph()
+// Generate split polygon to squares and build visibility graph, squares of extra obstacles are blocked
+// ctx is checked between columns of squares (nil ctx is context.Background()), on cancel previously generated data is kept
+func (g *Grid) Generate(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	squares, visSquares, err := g.generateSquares(ctx)
+	if err != nil {
+		return err
+	}
+
+	edges := outlineEdges(append([][]geom.Vector2{g.polygon}, g.holes...)...)
+	if g.costMap != nil {
+		edges = g.costMap.edges()
+	}
+
+	var (
+		l               = newLattice(squares, visSquares, g.squareSize, g.connectivity)
+		ids             = g.obstaclePool.IDs()
+		obstacleSquares = make(map[uint32][]int32, len(ids))
+		blocked         []uint16
+		changed         []int32
+	)
+
+	if l != nil && len(ids) > 0 {
+		blocked = make([]uint16, len(visSquares))
+		for i, obstacle := range g.obstaclePool.GetList() {
+			obstacleSquares[ids[i]] = l.obstacleSquares(visSquares, obstacle)
+			changed = blockSquares(blocked, obstacleSquares[ids[i]], changed)
+		}
+	}
+
+	vis := generateGraph(visSquares, g.connectivity)
+	if l != nil {
+		l.deletePinches(vis)
+	}
+
+	state := &snapshot{visibilityGraph: vis.Clip(), lattice: l}
+	if len(changed) > 0 {
+		state = state.update(visSquares, blocked, changed, g.Cost)
+	}
+
+	if g.clusterSize > 1 {
+		if state.hierarchy, err = g.buildHierarchy(ctx, state.lattice, state.visibilityGraph); err != nil {
+			return err
+		}
+	}
+
+	if g.connectivity.diagonals() {
+		state.jumpPoints = newJumpPoints(state.lattice)
+	}
+	state.raycastObstacles = g.raycastObstacles()
+	g.squares, g.visSquares = squares, visSquares
+	g.areaIndex = newAreaIndex(visSquares)
+	g.raycaster = newRaycaster(edges, g.squareSize)
+	g.lattice, g.obstacleSquares = l, obstacleSquares
+	g.state.Store(state)
+	g.changes.Record(internal.Change[bounds]{Full: true})
+	g.reportProgress(1)
 
 	return nil
 }
 
+// SearchPath finds path by Jump Point Search if it's enabled for grid or query and areas don't change costs,
+// otherwise long paths are searched over clusters if hierarchy is enabled (WithHierarchy).
+// Queries with obstacles, area filters or area costs are searched over visibility graph.
+// This method makes Grid implement the graphs.PathSearcher interface.
+func (g *Grid) SearchPath(start, dest geom.Vector2, navOpts *graphs.NavOpts) ([]geom.Vector2, bool) {
+	if (navOpts != nil && len(navOpts.Obstacles) > 0) || navOpts.HasAreaFilter() || navOpts.HasAreaCosts() {
+		return nil, false
+	}
+
+	state := g.state.Load()
+	if state.jumpPoints != nil && (g.jumpPointSearch || (navOpts != nil && navOpts.JumpPointSearch)) && !g.weighted() {
+		return state.jumpPoints.search(start, dest, g.costFunc, g.estimateFunc), true
+	}
+
+	if state.hierarchy != nil {
+		return state.hierarchy.search(g, state.visibilityGraph, start, dest)
+	}
+
+	return nil, false
+}
+
+func (g *Grid) reportProgress(progress float32) {
+	if g.progressFunc != nil {
+		g.progressFunc(progress)
+	}
+}
+
 func (g *Grid) ContainsPoint(point geom.Vector2) bool {
+	if g.costMap != nil {
+		return g.costMap.containsPoint(point)
+	}
+
 	return g.isInsidePolygonWithHoles(point)
 }
 
 func (g *Grid) GetVisibility(navOpts *graphs.NavOpts) graphs.Graph[geom.Vector2] {
-	vis := g.visibilityGraph.Copy()
+	state := g.state.Load()
+	vis := state.visibilityGraph.Copy()
 	if navOpts == nil {
 		return vis
 	}
 
 	if navOpts.Obstacles != nil {
 		// cut graph with obstacles
-		g.updateGraphWithObstacles(vis, navOpts.Obstacles)
+		g.updateGraphWithObstacles(vis, navOpts.Obstacles, navOpts.AgentRadius)
+	}
+
+	if navOpts.HasAreaFilter() {
+		g.cutGraphWithAreas(vis, state, navOpts)
 	}
 
 	return vis
 }
 
 func (g *Grid) Cost(a, b geom.Vector2) float32 {
-	return g.costFunc(a, b)
+	return g.segmentCost(g.areas, a, b)
+}
+
+// segmentCost return cost of segment by areas, it's multiplied by costs of cells if grid has cost map
+func (g *Grid) segmentCost(areas *mesh.Areas, a, b geom.Vector2) float32 {
+	if g.costMap == nil {
+		return areas.SegmentCost(a, b, g.costFunc)
+	}
+
+	return g.costMap.segmentCost(a, b, func(a, b geom.Vector2) float32 {
+		return areas.SegmentCost(a, b, g.costFunc)
+	})
+}
+
+// minCost return the smallest multiplier of segment cost by areas and cells
+func (g *Grid) minCost(areas *mesh.Areas) float32 {
+	if g.costMap == nil {
+		return areas.MinCost()
+	}
+
+	return areas.MinCost() * g.costMap.minCost
+}
+
+// weighted checks if areas or cells change cost of segments
+func (g *Grid) weighted() bool {
+	return g.areas.Weighted() || (g.costMap != nil && g.costMap.weighted)
+}
+
+// QueryCosts return cost and estimate functions with area costs overridden by navOpts
+// This method makes Grid implement the graphs.QueryCoster interface.
+func (g *Grid) QueryCosts(navOpts *graphs.NavOpts) (cost, estimate func(a, b geom.Vector2) float32) {
+	if !navOpts.HasAreaCosts() {
+		return g.Cost, g.Estimate
+	}
+
+	areas := g.areas.WithCosts(navOpts.AreaCosts)
+	cost = func(a, b geom.Vector2) float32 {
+		return g.segmentCost(areas, a, b)
+	}
+	estimate = func(a, b geom.Vector2) float32 {
+		return g.estimateFunc(a, b) * g.minCost(areas)
+	}
+
+	return cost, estimate
+}
+
+// Estimate return length of moves along sides and diagonals (octile distance, manhattan for Connectivity4) or costFunc
+// if it's set, multiplied by the cheapest area and cell costs, so it's never bigger than cost of path
+// This method makes Grid implement the graphs.Estimator interface.
+func (g *Grid) Estimate(a, b geom.Vector2) float32 {
+	return g.estimateFunc(a, b) * g.minCost(g.areas)
 }
 
 const (
@@ -89,47 +284,90 @@ func quantizeFloat(f float32) int64 {
 	return int64(f * scaleFactor)
 }
 
+// IsRaycastHit checks if segment crosses or touches outline of polygon or holes, only squares which segment goes through are checked.
+// Extra obstacles which aren't viewable are checked if WithObstacleRaycasts is enabled.
+// Segment out of polygon bounds isn't hit
 func (g *Grid) IsRaycastHit(start, end geom.Vector2) bool {
-	return false
-}
-
-func (g *Grid) GetClosestPoint(point geom.Vector2) (geom.Vector2, bool) {
-	closest := float32(math.MaxFloat32)
-	closestPoint := geom.Vector2{}
-	for _, visSq := range g.visSquares {
-		for _, v := range []geom.Vector2{visSq.A, visSq.B, visSq.C, visSq.D, visSq.Center} {
-			dist := geom.Distance(point, v)
-			if dist < closest {
-				closest = dist
-				closestPoint = v
-			}
+	for _, obstacle := range g.state.Load().raycastObstacles {
+		if isObstacleHit(obstacle, start, end) {
+			return true
 		}
 	}
 
-	if closest == math.MaxFloat32 {
-		return closestPoint, false
+	return g.raycaster != nil && g.raycaster.isHit(start, end)
+}
+
+// GetClosestPoint return the closest vertex or center of visible square which isn't blocked by extra obstacles
+// only squares around point are checked, so cost of query doesn't depend on size of grid
+func (g *Grid) GetClosestPoint(point geom.Vector2) (geom.Vector2, bool) {
+	state := g.state.Load()
+	if state.lattice == nil {
+		return geom.Vector2{}, false
 	}
 
-	return closestPoint, true
+	return state.lattice.closestPoint(point)
 }
 
 // AggregationGraph add start and dest points to existing pathfinder graph
+// squares of areas forbidden by navOpts are cut from the graph
 func (g *Grid) AggregationGraph(start, dest geom.Vector2, navOpts *graphs.NavOpts) graphs.Graph[geom.Vector2] {
-	vis := g.visibilityGraph.Copy()
+	state := g.state.Load()
+	vis := state.visibilityGraph.Copy()
 
 	// add start & dest points to graph
-	g.addStartDestPointsToGraph(vis, start, dest)
+	g.addStartDestPointsToGraph(vis, state, start, dest, navOpts)
 
 	if navOpts != nil {
 		if navOpts.Obstacles != nil {
 			// cut graph with obstacles
-			g.updateGraphWithObstacles(vis, navOpts.Obstacles)
+			g.updateGraphWithObstacles(vis, navOpts.Obstacles, navOpts.AgentRadius, start, dest)
+		}
+
+		if navOpts.HasAreaFilter() {
+			g.cutGraphWithAreas(vis, state, navOpts)
 		}
 	}
 
 	return vis
 }
 
+// Corridor return indexes of visible squares which path goes through, squares blocked by extra obstacles are skipped
+// each path segment is square side, diagonal or link of square vertex with start/dest, so it lies in one square.
+// This method makes Grid implement the graphs.PathDescriber interface.
+func (g *Grid) Corridor(path []geom.Vector2, _ *graphs.NavOpts) []int32 {
+	state := g.state.Load()
+	corridor := make([]int32, 0, len(path))
+	if state.lattice == nil {
+		return corridor
+	}
+
+	for i := 0; i < len(path)-1; i++ {
+		cells := state.lattice.locate(path[i].Lerp(path[i+1], 0.5))
+		if len(cells) == 0 {
+			continue
+		}
+
+		// middle on common side of squares belongs to square with the lowest index
+		square := int32(math.MaxInt32)
+		for _, c := range cells {
+			idx, _ := state.lattice.square(c)
+			square = min(square, idx)
+		}
+
+		if len(corridor) == 0 || corridor[len(corridor)-1] != square {
+			corridor = append(corridor, square)
+		}
+	}
+
+	return corridor
+}
+
+// SnapPoint grid doesn't search out of area, so point is never snapped
+// This method makes Grid implement the graphs.PathDescriber interface.
+func (g *Grid) SnapPoint(point geom.Vector2, _ *graphs.NavOpts) (geom.Vector2, bool) {
+	return point, false
+}
+
 // Squares return copied list of squares
 func (g *Grid) Squares() []Square {
 	cSquares := make([]Square, len(g.squares))
@@ -138,7 +376,7 @@ func (g *Grid) Squares() []Square {
 	return cSquares
 }
 
-// VisibleSquares return copied list of squares
+// VisibleSquares return copied list of squares, squares blocked by extra obstacles are kept
 func (g *Grid) VisibleSquares() []Square {
 	cSquares := make([]Square, len(g.visSquares))
 	copy(cSquares, g.visSquares)
@@ -146,99 +384,119 @@ func (g *Grid) VisibleSquares() []Square {
 	return cSquares
 }
 
-func (g *Grid) AddObstacles(...*mesh.Hole) []uint32 {
-	return nil
+// addStartDestPointsToGraph link start and dest with vertices of squares which contain them, squares are found by lattice
+func (g *Grid) addStartDestPointsToGraph(vis graphs.Graph[geom.Vector2], state *snapshot, start geom.Vector2, dest geom.Vector2, navOpts *graphs.NavOpts) {
+	if state.lattice == nil {
+		return
+	}
+
+	for _, point := range []geom.Vector2{start, dest} {
+		for _, c := range state.lattice.locate(point) {
+			idx, _ := state.lattice.square(c)
+			square := g.visSquares[idx]
+			if !navOpts.AreaAllowed(square.Area) {
+				continue
+			}
+
+			// corners which aren't passed by connectivity aren't linked
+			for i, corner := range corners(c) {
+				if state.lattice.present[state.lattice.vertexID(corner)] {
+					vis.LinkBoth([4]geom.Vector2{square.A, square.B, square.C, square.D}[i], point)
+				}
+			}
+		}
+	}
 }
 
-func (g *Grid) RemoveObstacles(...uint32) {}
+// updateGraphWithObstacles delete vertices & edges of graph which are blocked by obstacles
+// if agentRadius is set, vertices & edges closer than agent radius to obstacle are blocked too
+// edges of extra points (start, dest) are checked in addition to square vertices
+func (g *Grid) updateGraphWithObstacles(vis graphs.Graph[geom.Vector2], obstacles []obstacles.Obstacle, agentRadius float32, extra ...geom.Vector2) {
+	for _, point := range extra {
+		for _, obstacle := range obstacles {
+			for _, neighbour := range vis.Neighbours(point) {
+				if isSegmentBlocked(obstacle.GetPolygon(), point, neighbour, agentRadius) {
+					vis.DeleteNeighbour(point, neighbour)
+				}
+			}
+		}
+	}
 
-func (g *Grid) addStartDestPointsToGraph(vis graphs.Graph[geom.Vector2], start geom.Vector2, dest geom.Vector2) {
-	for _, square := range g.visSquares {
-		var (
-			a = square.A
-			b = square.B
-			c = square.C
-			d = square.D
-		)
+	if g.lattice == nil {
+		return
+	}
 
-		if square.isPointInsideSquare(start) {
-			vis.LinkBoth(a, start)
-			vis.LinkBoth(b, start)
-			vis.LinkBoth(c, start)
-			vis.LinkBoth(d, start)
+	for _, obstacle := range obstacles {
+		obstaclePolygon := obstacle.GetPolygon()
+		if len(obstaclePolygon) == 0 {
+			continue
 		}
 
-		if square.isPointInsideSquare(dest) {
-			vis.LinkBoth(a, dest)
-			vis.LinkBoth(b, dest)
-			vis.LinkBoth(c, dest)
-			vis.LinkBoth(d, dest)
+		// only squares which vertices or edges could be closer than agent radius to obstacle are checked
+		var (
+			margin = agentRadius + 3*g.squareSize
+			lo, hi = obstaclePolygon[0], obstaclePolygon[0]
+		)
+
+		for _, point := range obstaclePolygon {
+			lo.X, lo.Y = min(lo.X, point.X), min(lo.Y, point.Y)
+			hi.X, hi.Y = max(hi.X, point.X), max(hi.Y, point.Y)
 		}
-	}
-}
 
-func (g *Grid) updateGraphWithObstacles(vis graphs.Graph[geom.Vector2], obstacles []obstacles.Obstacle) {
-	for _, square := range g.visSquares {
-		for _, obstacle := range obstacles {
-			var (
-				a               = square.A
-				b               = square.B
-				c               = square.C
-				d               = square.D
-				obstaclePolygon = obstacle.GetPolygon()
-			)
+		for _, idx := range g.lattice.squaresIn(geom.Vector2{X: lo.X - margin, Y: lo.Y - margin}, geom.Vector2{X: hi.X + margin, Y: hi.Y + margin}) {
+			square := g.visSquares[idx]
 
 			// is squire center around or inside obstacle
-			if !obstacle.IsPointAround(square.Center, g.squareSize) {
+			if !obstacle.IsPointAround(square.Center, g.squareSize+agentRadius) {
 				continue
 			}
 
-			// check edges list
-			for _, neighbour := range vis.Neighbours(a) {
-				if isLineSegmentInsidePolygon(obstaclePolygon, a, neighbour) {
-					vis.DeleteNeighbour(a, neighbour)
+			for _, point := range []geom.Vector2{square.A, square.B, square.C, square.D} {
+				// check edges list
+				for _, neighbour := range vis.Neighbours(point) {
+					if isSegmentBlocked(obstaclePolygon, point, neighbour, agentRadius) {
+						vis.DeleteNeighbour(point, neighbour)
+					}
 				}
-			}
 
-			for _, neighbour := range vis.Neighbours(b) {
-				if isLineSegmentInsidePolygon(obstaclePolygon, b, neighbour) {
-					vis.DeleteNeighbour(b, neighbour)
+				// check vertex list
+				if isPointBlocked(obstaclePolygon, point, agentRadius) {
+					vis.DeleteNode(point)
 				}
 			}
+		}
+	}
+}
 
-			for _, neighbour := range vis.Neighbours(c) {
-				if isLineSegmentInsidePolygon(obstaclePolygon, c, neighbour) {
-					vis.DeleteNeighbour(c, neighbour)
-				}
-			}
+// cutGraphWithAreas delete edges of graph which lie only in squares of areas forbidden by navOpts
+// sides of allowed squares are kept, so path could go along forbidden area
+func (g *Grid) cutGraphWithAreas(vis graphs.Graph[geom.Vector2], state *snapshot, navOpts *graphs.NavOpts) {
+	if g.areaIndex == nil {
+		return
+	}
+
+	for area, squares := range g.areaIndex.squares {
+		if navOpts.AreaAllowed(area) {
+			continue
+		}
 
-			for _, neighbour := range vis.Neighbours(d) {
-				if isLineSegmentInsidePolygon(obstaclePolygon, d, neighbour) {
-					vis.DeleteNeighbour(d, neighbour)
+		for _, square := range squares {
+			for _, edge := range g.visSquares[square].graphEdges() {
+				if g.areaIndex.sharedAllowed(edge, g.visSquares, state, navOpts) {
+					continue
 				}
-			}
 
-			// check vertex list
-			if pointInPolygon(a, obstaclePolygon) {
-				vis.DeleteNode(a)
-			}
-			if pointInPolygon(b, obstaclePolygon) {
-				vis.DeleteNode(b)
-			}
-			if pointInPolygon(c, obstaclePolygon) {
-				vis.DeleteNode(c)
-			}
-			if pointInPolygon(d, obstaclePolygon) {
-				vis.DeleteNode(d)
+				vis.DeleteNeighbour(edge.A, edge.B)
+				vis.DeleteNeighbour(edge.B, edge.A)
 			}
 		}
 	}
 }
 
-// generateGraph create visibility graph based on squares
-func (g *Grid) generateGraph() graphs.Graph[geom.Vector2] {
+// generateGraph create visibility graph based on squares, diagonals are linked if connectivity allows them
+func generateGraph(visSquares []Square, connectivity Connectivity) graphs.Graph[geom.Vector2] {
 	vis := make(graphs.Graph[geom.Vector2])
-	for _, square := range g.visSquares {
+	for _, square := range visSquares {
 		var (
 			a, b, c, d = square.A, square.B, square.C, square.D
 		)
@@ -247,8 +505,10 @@ func (g *Grid) generateGraph() graphs.Graph[geom.Vector2] {
 		vis.LinkBoth(a, d)
 		vis.LinkBoth(c, b)
 		vis.LinkBoth(c, d)
-		vis.LinkBoth(a, c)
-		vis.LinkBoth(b, d)
+		if connectivity.diagonals() {
+			vis.LinkBoth(a, c)
+			vis.LinkBoth(b, d)
+		}
 	}
 
 	return vis
@@ -256,7 +516,13 @@ func (g *Grid) generateGraph() graphs.Graph[geom.Vector2] {
 
 // generateSquares create list of squares based on polygon, holes and squareSize
 // each square has info about vertices and if each vertex inside polygon to calculate graph
-func (g *Grid) generateSquares() ([]Square, []Square) {
+// progress of squares generation is reported by columns in range 0..0.9
+func (g *Grid) generateSquares(ctx context.Context) ([]Square, []Square, error) {
+	if g.costMap != nil {
+		squares, visSquares := g.costMap.generateSquares(g.areas)
+		return squares, visSquares, nil
+	}
+
 	var (
 		squareSize = g.squareSize
 		polygon    = g.polygon
@@ -285,6 +551,11 @@ func (g *Grid) generateSquares() ([]Square, []Square) {
 
 	// Iterate over the grid
 	for x := minX; x < maxX; x += squareSize {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		g.reportProgress(0.9 * (x - minX) / (maxX - minX))
 		for y := minY; y < maxY; y += squareSize {
 			var (
 				isA, isB, isC, isD, isCenter bool
@@ -323,6 +594,7 @@ func (g *Grid) generateSquares() ([]Square, []Square) {
 				C:        c,
 				D:        d,
 				Center:   center,
+				Area:     g.areas.AreaAt(center),
 				isA:      isA,
 				isB:      isB,
 				isC:      isC,
@@ -337,7 +609,7 @@ func (g *Grid) generateSquares() ([]Square, []Square) {
 		}
 	}
 
-	return squares, visSquares
+	return squares, visSquares, nil
 }
 
 // pointInPolygon checks if a point p is inside a polygon using the ray casting method.
@@ -406,6 +678,74 @@ func isLineSegmentInsidePolygon(polygon []geom.Vector2, lineStart, lineEnd geom.
 	return false
 }
 
+// isPointBlocked checks if point is inside obstacle polygon or closer than agent radius to it
+func isPointBlocked(polygon []geom.Vector2, point geom.Vector2, agentRadius float32) bool {
+	if pointInPolygon(point, polygon) {
+		return true
+	}
+
+	return agentRadius > 0 && pointPolygonDistance(polygon, point) < agentRadius
+}
+
+// isSegmentBlocked checks if segment intersects obstacle polygon or closer than agent radius to it
+func isSegmentBlocked(polygon []geom.Vector2, a, b geom.Vector2, agentRadius float32) bool {
+	if isLineSegmentInsidePolygon(polygon, a, b) {
+		return true
+	}
+
+	if agentRadius <= 0 {
+		return false
+	}
+
+	n := len(polygon)
+	for i := 0; i < n; i++ {
+		p1 := polygon[i]
+		p2 := polygon[(i+1)%n]
+		if geom.Distance(p1, closestPointOnSegment(p1, a, b)) < agentRadius ||
+			geom.Distance(a, closestPointOnSegment(a, p1, p2)) < agentRadius ||
+			geom.Distance(b, closestPointOnSegment(b, p1, p2)) < agentRadius {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pointPolygonDistance return distance from point to polygon outline
+func pointPolygonDistance(polygon []geom.Vector2, point geom.Vector2) float32 {
+	minDist := float32(math.MaxFloat32)
+	n := len(polygon)
+	for i := 0; i < n; i++ {
+		dist := geom.Distance(point, closestPointOnSegment(point, polygon[i], polygon[(i+1)%n]))
+		if dist < minDist {
+			minDist = dist
+		}
+	}
+
+	return minDist
+}
+
+func closestPointOnSegment(p, a, b geom.Vector2) geom.Vector2 {
+	ap := geom.Vector2{X: p.X - a.X, Y: p.Y - a.Y}
+	ab := geom.Vector2{X: b.X - a.X, Y: b.Y - a.Y}
+
+	dotProd := ap.X*ab.X + ap.Y*ab.Y
+	lenSq := ab.X*ab.X + ab.Y*ab.Y
+
+	if lenSq == 0 { // a and b are the same point
+		return a
+	}
+
+	t := dotProd / lenSq
+	if t < 0 {
+		return a
+	} else if t > 1 {
+		return b
+	}
+
+	return geom.Vector2{X: a.X + t*ab.X, Y: a.Y + t*ab.Y}
+}
+
 // Function to check if two lines intersect
 func doLinesIntersect(p1, p2, q1, q2 geom.Vector2) bool {
 	// Convert points to segments
@@ -0,0 +1,477 @@
+package grid
+
+import (
+	"container/heap"
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/bolom009/geom"
+	"github.com/bolom009/pathfind/graphs"
+)
+
+// maxEntranceRun is length of entrance which is represented by its middle vertex, longer entrances are represented by both ends
+const maxEntranceRun = 6
+
+// hierarchy is abstract graph of clusters (HPA*), clusters are clusterSize x clusterSize squares
+// Neighbour clusters share vertices of their border line, so transition vertex is abstract node of both clusters.
+// Abstract edges keep paths over square corners which are found inside cluster, so path is refined without search
+type hierarchy struct {
+	*lattice
+	clusterSize int32
+	transitions map[cell][]geom.Vector2
+	edges       map[geom.Vector2][]abstractEdge
+}
+
+// abstractEdge is the cheapest path between abstract nodes inside cluster, path contains both nodes
+type abstractEdge struct {
+	to      geom.Vector2
+	cost    float32
+	path    []geom.Vector2
+	cluster cell
+}
+
+// buildHierarchy split visible squares to clusters, finds entrances on cluster borders and connects them by paths inside clusters
+// ctx is checked between clusters, progress is reported in range 0.9..1
+func (g *Grid) buildHierarchy(ctx context.Context, l *lattice, vis graphs.Graph[geom.Vector2]) (*hierarchy, error) {
+	if l == nil {
+		return nil, nil
+	}
+
+	h := &hierarchy{
+		lattice:     l,
+		clusterSize: g.clusterSize,
+		transitions: make(map[cell][]geom.Vector2),
+		edges:       make(map[geom.Vector2][]abstractEdge),
+	}
+
+	clusters := cell{x: l.width/h.clusterSize + 1, y: l.height/h.clusterSize + 1}
+	for cx := int32(0); cx < clusters.x; cx++ {
+		for cy := int32(0); cy < clusters.y; cy++ {
+			// right and top borders of cluster, left and bottom ones are added by previous clusters
+			border := (cx + 1) * h.clusterSize
+			h.addEntrances(vis, cell{x: border, y: cy * h.clusterSize}, cell{y: 1}, cell{x: cx, y: cy}, cell{x: cx + 1, y: cy})
+			border = (cy + 1) * h.clusterSize
+			h.addEntrances(vis, cell{x: cx * h.clusterSize, y: border}, cell{x: 1}, cell{x: cx, y: cy}, cell{x: cx, y: cy + 1})
+		}
+	}
+
+	step := 0.1 / float32(len(h.transitions))
+	for i, cluster := range slices.SortedFunc(maps.Keys(h.transitions), compareCells) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		g.reportProgress(0.9 + float32(i)*step)
+		h.connectCluster(vis, cluster, g.Cost)
+	}
+
+	return h, nil
+}
+
+// update return copy of hierarchy over lattice and visibility graph where squares were blocked or unblocked
+// Entrances and abstract edges are rebuilt only for clusters around changed squares, because borders are shared with neighbours
+// and border vertex could be removed with its squares in diagonal cluster
+func (h *hierarchy) update(l *lattice, vis graphs.Graph[geom.Vector2], changed []cell, cost func(a, b geom.Vector2) float32) *hierarchy {
+	var (
+		next = &hierarchy{
+			lattice:     l,
+			clusterSize: h.clusterSize,
+			transitions: maps.Clone(h.transitions),
+			edges:       maps.Clone(h.edges),
+		}
+		dirty = make(map[cell]struct{})
+	)
+
+	for _, square := range changed {
+		cluster := h.clusterOf(square)
+		for cx := cluster.x - 1; cx <= cluster.x+1; cx++ {
+			for cy := cluster.y - 1; cy <= cluster.y+1; cy++ {
+				if cx >= 0 && cy >= 0 {
+					dirty[cell{x: cx, y: cy}] = struct{}{}
+				}
+			}
+		}
+	}
+
+	// old transitions lose edges inside dirty clusters, edges of other clusters are kept
+	isDirty := func(edge abstractEdge) bool {
+		_, ok := dirty[edge.cluster]
+		return ok
+	}
+
+	for cluster := range dirty {
+		for _, t := range h.transitions[cluster] {
+			if edges := slices.DeleteFunc(slices.Clone(next.edges[t]), isDirty); len(edges) > 0 {
+				next.edges[t] = edges
+			} else {
+				delete(next.edges, t)
+			}
+		}
+
+		delete(next.transitions, cluster)
+	}
+
+	clusters := slices.SortedFunc(maps.Keys(dirty), compareCells)
+	for _, cluster := range clusters {
+		var (
+			left   = cluster.x * h.clusterSize
+			bottom = cluster.y * h.clusterSize
+		)
+
+		// borders of the first column and row have no neighbours, so they don't have entrances
+		if cluster.x > 0 {
+			next.addEntrances(vis, cell{x: left, y: bottom}, cell{y: 1}, cluster)
+		}
+		if cluster.y > 0 {
+			next.addEntrances(vis, cell{x: left, y: bottom}, cell{x: 1}, cluster)
+		}
+
+		next.addEntrances(vis, cell{x: left + h.clusterSize, y: bottom}, cell{y: 1}, cluster)
+		next.addEntrances(vis, cell{x: left, y: bottom + h.clusterSize}, cell{x: 1}, cluster)
+	}
+
+	for _, cluster := range clusters {
+		// edges of transition could be shared with previous hierarchy, so appended edges must not write to it
+		for _, t := range next.transitions[cluster] {
+			next.edges[t] = slices.Clip(next.edges[t])
+		}
+
+		next.connectCluster(vis, cluster, cost)
+	}
+
+	return next
+}
+
+// addEntrances finds runs of linked vertices on border line from first vertex by dir and adds their representatives to clusters
+func (h *hierarchy) addEntrances(vis graphs.Graph[geom.Vector2], first, dir cell, clusters ...cell) {
+	run := make([]geom.Vector2, 0, h.clusterSize+1)
+	flush := func() {
+		switch {
+		case len(run) == 0:
+		case len(run) <= maxEntranceRun:
+			h.addTransition(run[len(run)/2], clusters...)
+		default:
+			h.addTransition(run[0], clusters...)
+			h.addTransition(run[len(run)-1], clusters...)
+		}
+
+		run = run[:0]
+	}
+
+	for i := int32(0); i <= h.clusterSize; i++ {
+		point, ok := h.vertex(cell{x: first.x + dir.x*i, y: first.y + dir.y*i})
+		if !ok {
+			flush()
+			continue
+		}
+
+		if len(run) > 0 && !slices.Contains(vis[run[len(run)-1]], point) {
+			flush()
+		}
+
+		run = append(run, point)
+	}
+
+	flush()
+}
+
+func (h *hierarchy) addTransition(point geom.Vector2, clusters ...cell) {
+	for _, cluster := range clusters {
+		if !slices.Contains(h.transitions[cluster], point) {
+			h.transitions[cluster] = append(h.transitions[cluster], point)
+		}
+	}
+}
+
+// connectCluster adds abstract edges between transitions of cluster by paths which don't leave it
+func (h *hierarchy) connectCluster(vis graphs.Graph[geom.Vector2], cluster cell, cost func(a, b geom.Vector2) float32) {
+	transitions := h.transitions[cluster]
+	for _, from := range transitions {
+		dist, prev := h.searchCluster(vis, from, cluster, vis[from], cost)
+		for _, to := range transitions {
+			if d, ok := dist[to]; ok && to != from {
+				h.edges[from] = append(h.edges[from], abstractEdge{to: to, cost: d, path: reconstruct(prev, from, to), cluster: cluster})
+			}
+		}
+	}
+}
+
+// searchCluster finds the cheapest paths from point to vertices of cluster by Dijkstra search, links are neighbours of point
+func (h *hierarchy) searchCluster(vis graphs.Graph[geom.Vector2], point geom.Vector2, cluster cell, links []geom.Vector2, cost func(a, b geom.Vector2) float32) (map[geom.Vector2]float32, map[geom.Vector2]geom.Vector2) {
+	var (
+		dist = map[geom.Vector2]float32{point: 0}
+		prev = make(map[geom.Vector2]geom.Vector2)
+		open = &nodeQueue{{node: point}}
+	)
+
+	for open.Len() > 0 {
+		current := heap.Pop(open).(queueNode)
+		if current.priority > dist[current.node] {
+			continue
+		}
+
+		neighbours := vis[current.node]
+		if current.node == point {
+			neighbours = links
+		}
+
+		for _, nb := range neighbours {
+			if !h.inCluster(h.cellOf(nb), cluster) {
+				continue
+			}
+
+			d := current.priority + cost(current.node, nb)
+			if old, ok := dist[nb]; !ok || d < old {
+				dist[nb], prev[nb] = d, current.node
+				heap.Push(open, queueNode{node: nb, priority: d})
+			}
+		}
+	}
+
+	return dist, prev
+}
+
+// search finds path over abstract graph of clusters and refines it by search over visibility graph inside corridor
+// of clusters around abstract path. Points of the same or neighbour clusters are searched over visibility graph (false)
+func (h *hierarchy) search(g *Grid, vis graphs.Graph[geom.Vector2], start, dest geom.Vector2) ([]geom.Vector2, bool) {
+	startCells, destCells := h.locate(start), h.locate(dest)
+	if len(startCells) == 0 || len(destCells) == 0 {
+		return nil, false
+	}
+
+	startCluster, destCluster := h.clusterOf(startCells[0]), h.clusterOf(destCells[0])
+	if abs(startCluster.x-destCluster.x) <= 1 && abs(startCluster.y-destCluster.y) <= 1 {
+		return nil, false
+	}
+
+	var (
+		startLinks           = h.cornerPoints(startCells)
+		destLinks            = h.cornerPoints(destCells)
+		startDist, startPrev = h.searchCluster(vis, start, startCluster, startLinks, g.Cost)
+		destDist, destPrev   = h.searchCluster(vis, dest, destCluster, destLinks, g.Cost)
+		startEdges           = make([]abstractEdge, 0, len(h.transitions[startCluster]))
+		destEdges            = make(map[geom.Vector2]abstractEdge, len(h.transitions[destCluster]))
+	)
+
+	for _, t := range h.transitions[startCluster] {
+		if d, ok := startDist[t]; ok {
+			startEdges = append(startEdges, abstractEdge{to: t, cost: d, path: reconstruct(startPrev, start, t)})
+		}
+	}
+
+	for _, t := range h.transitions[destCluster] {
+		if d, ok := destDist[t]; ok {
+			path := reconstruct(destPrev, dest, t)
+			slices.Reverse(path)
+			destEdges[t] = abstractEdge{to: dest, cost: d, path: path}
+		}
+	}
+
+	path := h.searchAbstract(start, dest, startEdges, destEdges, g.Estimate)
+	if path == nil {
+		return nil, true
+	}
+
+	if refined := h.refine(vis, path, startLinks, destLinks, g.Cost, g.Estimate); refined != nil {
+		return refined, true
+	}
+
+	return path, true
+}
+
+// refine finds the cheapest path from start to dest by A* search over vertices of corridor,
+// corridor is clusters which abstract path goes through and their neighbours, so path isn't bound to cluster entrances
+func (h *hierarchy) refine(vis graphs.Graph[geom.Vector2], path, startLinks, destLinks []geom.Vector2, cost, estimate func(a, b geom.Vector2) float32) []geom.Vector2 {
+	var (
+		start, dest = path[0], path[len(path)-1]
+		corridor    = make(map[cell]struct{})
+		dist        = map[geom.Vector2]float32{start: 0}
+		prev        = make(map[geom.Vector2]geom.Vector2)
+		open        = &nodeQueue{{node: start, priority: estimate(start, dest)}}
+	)
+
+	for _, point := range path[1 : len(path)-1] {
+		for _, cluster := range h.vertexClusters(h.cellOf(point)) {
+			for x := cluster.x - 1; x <= cluster.x+1; x++ {
+				for y := cluster.y - 1; y <= cluster.y+1; y++ {
+					corridor[cell{x: x, y: y}] = struct{}{}
+				}
+			}
+		}
+	}
+
+	inCorridor := func(point geom.Vector2) bool {
+		return slices.ContainsFunc(h.vertexClusters(h.cellOf(point)), func(cluster cell) bool {
+			_, ok := corridor[cluster]
+			return ok
+		})
+	}
+
+	relax := func(current, nb geom.Vector2, d float32) {
+		if old, ok := dist[nb]; !ok || d < old {
+			dist[nb], prev[nb] = d, current
+			heap.Push(open, queueNode{node: nb, priority: d + estimate(nb, dest)})
+		}
+	}
+
+	for open.Len() > 0 {
+		current := heap.Pop(open).(queueNode).node
+		if current == dest {
+			return reconstruct(prev, start, dest)
+		}
+
+		neighbours := vis[current]
+		if current == start {
+			neighbours = startLinks
+		}
+
+		for _, nb := range neighbours {
+			if inCorridor(nb) {
+				relax(current, nb, dist[current]+cost(current, nb))
+			}
+		}
+
+		if slices.Contains(destLinks, current) {
+			relax(current, dest, dist[current]+cost(current, dest))
+		}
+	}
+
+	return nil
+}
+
+// vertexClusters return clusters which contain vertex cell, vertex on cluster border belongs to all clusters around it
+func (h *hierarchy) vertexClusters(vertex cell) []cell {
+	clusters := make([]cell, 0, 4)
+	for _, x := range []int32{vertex.x, vertex.x - 1} {
+		for _, y := range []int32{vertex.y, vertex.y - 1} {
+			if x < 0 || y < 0 {
+				continue
+			}
+
+			if cluster := h.clusterOf(cell{x: x, y: y}); !slices.Contains(clusters, cluster) {
+				clusters = append(clusters, cluster)
+			}
+		}
+	}
+
+	return clusters
+}
+
+// searchAbstract finds path from start to dest over abstract graph by A* search and joins paths of its edges
+func (h *hierarchy) searchAbstract(start, dest geom.Vector2, startEdges []abstractEdge, destEdges map[geom.Vector2]abstractEdge, estimate func(a, b geom.Vector2) float32) []geom.Vector2 {
+	var (
+		score = map[geom.Vector2]float32{start: 0}
+		came  = make(map[geom.Vector2]abstractEdge)
+		from  = make(map[geom.Vector2]geom.Vector2)
+		open  = &nodeQueue{{node: start, priority: estimate(start, dest)}}
+	)
+
+	relax := func(current geom.Vector2, edge abstractEdge) {
+		s := score[current] + edge.cost
+		if old, ok := score[edge.to]; !ok || s < old {
+			score[edge.to], came[edge.to], from[edge.to] = s, edge, current
+			heap.Push(open, queueNode{node: edge.to, priority: s + estimate(edge.to, dest)})
+		}
+	}
+
+	for open.Len() > 0 {
+		current := heap.Pop(open).(queueNode).node
+		if current == dest {
+			return joinEdges(came, from, start, dest)
+		}
+
+		edges := h.edges[current]
+		if current == start {
+			edges = append(startEdges, edges...)
+		}
+
+		for _, edge := range edges {
+			relax(current, edge)
+		}
+
+		if edge, ok := destEdges[current]; ok {
+			relax(current, edge)
+		}
+	}
+
+	return nil
+}
+
+// cornerPoints return vertices of square cells, point is linked to them like to corners of squares which contain it
+func (h *hierarchy) cornerPoints(squares []cell) []geom.Vector2 {
+	points := make([]geom.Vector2, 0, 4*len(squares))
+	for _, square := range squares {
+		for _, c := range corners(square) {
+			if point, ok := h.vertex(c); ok && !slices.Contains(points, point) {
+				points = append(points, point)
+			}
+		}
+	}
+
+	return points
+}
+
+// clusterOf return cluster of square cell
+func (h *hierarchy) clusterOf(square cell) cell {
+	return cell{x: square.x / h.clusterSize, y: square.y / h.clusterSize}
+}
+
+// inCluster checks if vertex cell lies in cluster or on its border
+func (h *hierarchy) inCluster(vertex, cluster cell) bool {
+	return vertex.x >= cluster.x*h.clusterSize && vertex.x <= (cluster.x+1)*h.clusterSize &&
+		vertex.y >= cluster.y*h.clusterSize && vertex.y <= (cluster.y+1)*h.clusterSize
+}
+
+func reconstruct(prev map[geom.Vector2]geom.Vector2, from, to geom.Vector2) []geom.Vector2 {
+	path := []geom.Vector2{to}
+	for to != from {
+		to = prev[to]
+		path = append(path, to)
+	}
+
+	slices.Reverse(path)
+	return path
+}
+
+// joinEdges join paths of abstract edges from start to dest, shared vertices of edges are added once
+func joinEdges(came map[geom.Vector2]abstractEdge, from map[geom.Vector2]geom.Vector2, start, dest geom.Vector2) []geom.Vector2 {
+	edges := make([]abstractEdge, 0)
+	for node := dest; node != start; node = from[node] {
+		edges = append(edges, came[node])
+	}
+
+	path := []geom.Vector2{start}
+	for i := len(edges) - 1; i >= 0; i-- {
+		path = append(path, edges[i].path[1:]...)
+	}
+
+	return path
+}
+
+func compareCells(a, b cell) int {
+	if a.x != b.x {
+		return int(a.x - b.x)
+	}
+
+	return int(a.y - b.y)
+}
+
+type queueNode struct {
+	node     geom.Vector2
+	priority float32
+}
+
+// nodeQueue is min-heap of nodes by priority
+type nodeQueue []queueNode
+
+func (q nodeQueue) Len() int           { return len(q) }
+func (q nodeQueue) Less(i, j int) bool { return q[i].priority < q[j].priority }
+func (q nodeQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *nodeQueue) Push(x any)        { *q = append(*q, x.(queueNode)) }
+func (q *nodeQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,424 @@
+package grid
+
+import (
+	"container/heap"
+	"math"
+	"slices"
+
+	"github.com/bolom009/geom"
+)
+
+// directions of moves between lattice vertices, odd directions are diagonals
+var directions = [8]cell{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}}
+
+// noDirection is direction of vertex which is linked with start point, all its moves are successors
+const noDirection = 8
+
+// jumpPoints is lattice with successors of each vertex by direction of move which reaches it (Jump Point Search)
+// successors[v] byte d is mask of directions which aren't pruned after move d, they are computed by local
+// shortest paths around vertex, so squares sides and diagonals rules of visibility graph are followed exactly
+type jumpPoints struct {
+	*lattice
+	successors []uint64
+}
+
+func newJumpPoints(l *lattice) *jumpPoints {
+	if l == nil {
+		return nil
+	}
+
+	var (
+		jp   = &jumpPoints{lattice: l, successors: make([]uint64, len(l.vertices))}
+		memo = make(map[uint16]uint64)
+	)
+
+	for id, present := range l.present {
+		if present {
+			jp.successors[id] = jp.localSuccessors(int32(id), memo)
+		}
+	}
+
+	return jp
+}
+
+// update return copy of jump points over lattice where squares were blocked or unblocked,
+// successors are recomputed only for vertices which neighbourhood contains changed squares
+func (jp *jumpPoints) update(l *lattice, changed []cell) *jumpPoints {
+	var (
+		next = &jumpPoints{lattice: l, successors: slices.Clone(jp.successors)}
+		memo = make(map[uint16]uint64)
+	)
+
+	for _, square := range changed {
+		for x := max(square.x-1, 0); x <= min(square.x+2, l.width); x++ {
+			for y := max(square.y-1, 0); y <= min(square.y+2, l.height); y++ {
+				id := l.vertexID(cell{x: x, y: y})
+				next.successors[id] = 0
+				if l.present[id] {
+					next.successors[id] = next.localSuccessors(id, memo)
+				}
+			}
+		}
+	}
+
+	return next
+}
+
+// localSuccessors return successors masks of vertex, masks are memoized by neighbourhood
+func (jp *jumpPoints) localSuccessors(id int32, memo map[uint16]uint64) uint64 {
+	key := jp.neighbourhood(jp.vertexCell(id))
+	masks, ok := memo[key]
+	if !ok {
+		masks = localSuccessors(key, jp.connectivity)
+		memo[key] = masks
+	}
+
+	return masks
+}
+
+// neighbourhood return visibility of 4x4 squares around vertex, they define all moves between vertices of 3x3 block around it
+func (l *lattice) neighbourhood(c cell) uint16 {
+	var key uint16
+	for j := int32(-2); j <= 1; j++ {
+		for i := int32(-2); i <= 1; i++ {
+			if _, ok := l.square(cell{x: c.x + i, y: c.y + j}); ok {
+				key |= 1 << ((j+2)*4 + i + 2)
+			}
+		}
+	}
+
+	return key
+}
+
+// canMove checks if vertex c is linked with next vertex by direction d, squares are checked by visible function
+// diagonal lies in one square, side is linked if one of squares along it is visible.
+// Without corner cutting vertices between two diagonal blocked squares aren't linked
+func canMove(c cell, d int, connectivity Connectivity, visible func(cell) bool) bool {
+	dir := directions[d]
+	if !connectivity.cornerCutting() && (isPinch(c, visible) || isPinch(cell{x: c.x + dir.x, y: c.y + dir.y}, visible)) {
+		return false
+	}
+
+	switch {
+	case dir.x != 0 && dir.y != 0:
+		return connectivity.diagonals() && visible(cell{x: c.x + min(dir.x, 0), y: c.y + min(dir.y, 0)})
+	case dir.x != 0:
+		x := c.x + min(dir.x, 0)
+		return visible(cell{x: x, y: c.y}) || visible(cell{x: x, y: c.y - 1})
+	default:
+		y := c.y + min(dir.y, 0)
+		return visible(cell{x: c.x, y: y}) || visible(cell{x: c.x - 1, y: y})
+	}
+}
+
+// localSuccessors compute successors masks of vertex by visibility of squares around it
+// move p -> x -> m is pruned if m is reached from p around x not longer (shorter for diagonal moves),
+// ties of straight moves are broken by diagonal first and horizontal first order
+func localSuccessors(key uint16, connectivity Connectivity) uint64 {
+	var (
+		visible = func(c cell) bool {
+			return c.x >= -2 && c.x <= 1 && c.y >= -2 && c.y <= 1 && key&(1<<((c.y+2)*4+c.x+2)) != 0
+		}
+		masks uint64
+	)
+
+	for d := range directions {
+		parent := cell{x: -directions[d].x, y: -directions[d].y}
+		if !canMove(parent, d, connectivity, visible) {
+			continue
+		}
+
+		around := localDistances(parent, connectivity, visible)
+		for m := range directions {
+			if m == (d+4)%8 || !canMove(cell{}, m, connectivity, visible) {
+				continue
+			}
+
+			var (
+				via    = moveLength(d) + moveLength(m)
+				alt    = around[directions[m].y+1][directions[m].x+1]
+				pruned = alt <= via+1e-6
+			)
+
+			switch {
+			case d%2 == 1:
+				pruned = alt < via-1e-6
+			case (m+2)%4 == d%4 && math.Abs(alt-via) <= 1e-6:
+				// the only other path of turn is mirrored turn along sides of blocked square,
+				// one of them must be kept, so horizontal move goes first
+				pruned = d%4 == 2
+			}
+
+			if !pruned {
+				masks |= 1 << (d*8 + m)
+			}
+		}
+	}
+
+	return masks
+}
+
+// localDistances return lengths of the shortest paths from vertex to vertices of 3x3 block which don't go through its center
+func localDistances(from cell, connectivity Connectivity, visible func(cell) bool) [3][3]float64 {
+	var (
+		dist [3][3]float64
+		done [3][3]bool
+	)
+
+	for y := range dist {
+		for x := range dist[y] {
+			dist[y][x] = math.Inf(1)
+		}
+	}
+
+	dist[from.y+1][from.x+1] = 0
+	done[1][1] = true
+	for {
+		current, best := cell{}, math.Inf(1)
+		for y := range dist {
+			for x := range dist[y] {
+				if !done[y][x] && dist[y][x] < best {
+					current, best = cell{x: int32(x) - 1, y: int32(y) - 1}, dist[y][x]
+				}
+			}
+		}
+
+		if math.IsInf(best, 1) {
+			return dist
+		}
+
+		done[current.y+1][current.x+1] = true
+		for m, dir := range directions {
+			next := cell{x: current.x + dir.x, y: current.y + dir.y}
+			if next.x < -1 || next.x > 1 || next.y < -1 || next.y > 1 || !canMove(current, m, connectivity, visible) {
+				continue
+			}
+
+			if d := best + moveLength(m); d < dist[next.y+1][next.x+1] {
+				dist[next.y+1][next.x+1] = d
+			}
+		}
+	}
+}
+
+func moveLength(d int) float64 {
+	if d%2 == 1 {
+		return math.Sqrt2
+	}
+
+	return 1
+}
+
+// naturalSuccessors return mask of directions which are successors of move d on grid without obstacles
+func naturalSuccessors(d int) uint8 {
+	if d%2 == 0 {
+		return 1 << d
+	}
+
+	return 1<<d | 1<<(d-1) | 1<<((d+1)%8)
+}
+
+// successorsMask return directions which are searched from vertex reached by direction d
+func (jp *jumpPoints) successorsMask(c cell, d int) uint8 {
+	if d != noDirection {
+		return uint8(jp.successors[jp.vertexID(c)] >> (d * 8))
+	}
+
+	var mask uint8
+	for m := range directions {
+		if canMove(c, m, jp.connectivity, jp.visible) {
+			mask |= 1 << m
+		}
+	}
+
+	return mask
+}
+
+// jump moves from vertex by direction d until vertex with forced successors, goal vertex or dead end
+// diagonal move stops at vertex from which straight jump finds jump point
+func (jp *jumpPoints) jump(c cell, d int, goals map[int32]struct{}) (cell, bool) {
+	for {
+		if !canMove(c, d, jp.connectivity, jp.visible) {
+			return c, false
+		}
+
+		c = cell{x: c.x + directions[d].x, y: c.y + directions[d].y}
+		if _, ok := goals[jp.vertexID(c)]; ok {
+			return c, true
+		}
+
+		if jp.successorsMask(c, d)&^naturalSuccessors(d) != 0 {
+			return c, true
+		}
+
+		if d%2 == 1 {
+			if _, ok := jp.jump(c, d-1, goals); ok {
+				return c, true
+			}
+			if _, ok := jp.jump(c, (d+1)%8, goals); ok {
+				return c, true
+			}
+		}
+	}
+}
+
+// search finds the shortest path from start to dest by Jump Point Search, nodes of path are vertices of squares like in
+// visibility graph search. Points are linked to corners of squares which contain them, cost is measured by costFunc
+// and estimated by estimate
+func (jp *jumpPoints) search(start, dest geom.Vector2, costFunc, estimate func(a, b geom.Vector2) float32) []geom.Vector2 {
+	startCells, destCells := jp.locate(start), jp.locate(dest)
+	if len(startCells) == 0 || len(destCells) == 0 {
+		return nil
+	}
+
+	const (
+		startID = -1
+		destID  = -2
+	)
+
+	var (
+		goals  = make(map[int32]struct{}, 4*len(destCells))
+		score  = make(map[int32]float32)
+		parent = make(map[int32]int32)
+		closed = make(map[int32]bool)
+		open   = &idQueue{}
+	)
+
+	relax := func(from, to int32, cost float32) {
+		if old, ok := score[to]; ok && old <= cost {
+			return
+		}
+
+		score[to], parent[to] = cost, from
+		if to == destID {
+			heap.Push(open, idItem{id: to, priority: cost})
+			return
+		}
+
+		heap.Push(open, idItem{id: to, priority: cost + estimate(jp.vertices[to], dest)})
+	}
+
+	for _, square := range destCells {
+		for _, c := range corners(square) {
+			if id := jp.vertexID(c); jp.present[id] {
+				goals[id] = struct{}{}
+			}
+		}
+	}
+
+	for _, square := range startCells {
+		for _, c := range corners(square) {
+			if id := jp.vertexID(c); jp.present[id] {
+				relax(startID, id, costFunc(start, jp.vertices[id]))
+			}
+		}
+	}
+
+	for open.Len() > 0 {
+		id := heap.Pop(open).(idItem).id
+		if closed[id] {
+			continue
+		}
+
+		if id == destID {
+			return jp.reconstruct(parent, start, dest)
+		}
+
+		closed[id] = true
+		var (
+			c     = jp.vertexCell(id)
+			point = jp.vertices[id]
+			d     = noDirection
+		)
+
+		if from := parent[id]; from != startID {
+			d = directionOf(jp.vertexCell(from), c)
+		}
+
+		if _, ok := goals[id]; ok {
+			relax(id, destID, score[id]+costFunc(point, dest))
+		}
+
+		mask := jp.successorsMask(c, d)
+		for m := range directions {
+			if mask&(1<<m) == 0 {
+				continue
+			}
+
+			if next, ok := jp.jump(c, m, goals); ok {
+				nextID := jp.vertexID(next)
+				relax(id, nextID, score[id]+costFunc(point, jp.vertices[nextID]))
+			}
+		}
+	}
+
+	return nil
+}
+
+// reconstruct return path from start to dest, moves between jump points are split to square sides and diagonals
+func (jp *jumpPoints) reconstruct(parent map[int32]int32, start, dest geom.Vector2) []geom.Vector2 {
+	jumps := make([]int32, 0)
+	for id := parent[-2]; id != -1; id = parent[id] {
+		jumps = append(jumps, id)
+	}
+
+	path := []geom.Vector2{start}
+	for i := len(jumps) - 1; i >= 0; i-- {
+		to := jp.vertexCell(jumps[i])
+		if i == len(jumps)-1 {
+			path = append(path, jp.vertices[jumps[i]])
+			continue
+		}
+
+		c := jp.vertexCell(jumps[i+1])
+		d := directions[directionOf(c, to)]
+		for c != to {
+			c = cell{x: c.x + d.x, y: c.y + d.y}
+			path = append(path, jp.vertices[jp.vertexID(c)])
+		}
+	}
+
+	return append(path, dest)
+}
+
+// directionOf return direction of move between vertices on the same line or diagonal
+func directionOf(from, to cell) int {
+	dir := cell{x: sign(to.x - from.x), y: sign(to.y - from.y)}
+	for d, v := range directions {
+		if v == dir {
+			return d
+		}
+	}
+
+	return noDirection
+}
+
+func sign(v int32) int32 {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
+type idItem struct {
+	id       int32
+	priority float32
+}
+
+// idQueue is min-heap of vertex ids by priority
+type idQueue []idItem
+
+func (q idQueue) Len() int           { return len(q) }
+func (q idQueue) Less(i, j int) bool { return q[i].priority < q[j].priority }
+func (q idQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *idQueue) Push(x any)        { *q = append(*q, x.(idItem)) }
+func (q *idQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
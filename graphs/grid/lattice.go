@@ -0,0 +1,240 @@
+package grid
+
+import (
+	"math"
+	"slices"
+
+	"github.com/bolom009/geom"
+	"github.com/bolom009/pathfind/graphs"
+)
+
+// cell is position of square or vertex in grid lattice
+type cell struct {
+	x, y int32
+}
+
+// lattice is dense row-major index of visible squares and their vertices by position, square cell is position of its A vertex
+// squares contains indexes of visSquares by cell (-1 for invisible square), so point lookups check only squares around point
+type lattice struct {
+	origin     geom.Vector2
+	squareSize float32
+	width      int32
+	height     int32
+	squares    []int32
+	vertices   []geom.Vector2
+	present    []bool
+	visSquares []Square
+
+	// connectivity is rule of links between vertices, vertices which aren't passed by it aren't present
+	connectivity Connectivity
+}
+
+func newLattice(squares, visSquares []Square, squareSize float32, connectivity Connectivity) *lattice {
+	if len(squares) == 0 {
+		return nil
+	}
+
+	l := &lattice{origin: squares[0].A, squareSize: squareSize, visSquares: visSquares, connectivity: connectivity}
+	for _, square := range squares {
+		c := l.cellOf(square.A)
+		l.width, l.height = max(l.width, c.x+1), max(l.height, c.y+1)
+	}
+
+	l.squares = make([]int32, l.width*l.height)
+	for i := range l.squares {
+		l.squares[i] = -1
+	}
+
+	l.vertices = make([]geom.Vector2, (l.width+1)*(l.height+1))
+	l.present = make([]bool, len(l.vertices))
+	for i, square := range visSquares {
+		c := l.cellOf(square.A)
+		l.squares[c.y*l.width+c.x] = int32(i)
+		for _, point := range []geom.Vector2{square.A, square.B, square.C, square.D} {
+			id := l.vertexID(l.cellOf(point))
+			l.vertices[id], l.present[id] = point, true
+		}
+	}
+
+	if !connectivity.cornerCutting() {
+		for id, present := range l.present {
+			l.present[id] = present && !isPinch(l.vertexCell(int32(id)), l.visible)
+		}
+	}
+
+	return l
+}
+
+// clone return copy of lattice which visibility could be changed, vertices positions are shared
+func (l *lattice) clone() *lattice {
+	c := *l
+	c.squares, c.present = slices.Clone(l.squares), slices.Clone(l.present)
+	return &c
+}
+
+// cellOf return lattice position of square vertex
+func (l *lattice) cellOf(point geom.Vector2) cell {
+	return cell{
+		x: int32(math.Round(float64((point.X - l.origin.X) / l.squareSize))),
+		y: int32(math.Round(float64((point.Y - l.origin.Y) / l.squareSize))),
+	}
+}
+
+// square return index of visible square by its cell
+func (l *lattice) square(c cell) (int32, bool) {
+	if c.x < 0 || c.y < 0 || c.x >= l.width || c.y >= l.height {
+		return -1, false
+	}
+
+	idx := l.squares[c.y*l.width+c.x]
+	return idx, idx >= 0
+}
+
+// visible checks if square cell is visible
+func (l *lattice) visible(c cell) bool {
+	_, ok := l.square(c)
+	return ok
+}
+
+// deletePinches delete vertices which aren't passed by connectivity and their links from visibility graph
+func (l *lattice) deletePinches(vis graphs.Graph[geom.Vector2]) {
+	if l.connectivity.cornerCutting() {
+		return
+	}
+
+	for id := range l.vertices {
+		if c := l.vertexCell(int32(id)); isPinch(c, l.visible) {
+			point := l.vertices[id]
+			for _, neighbour := range vis[point] {
+				vis.DeleteNeighbour(neighbour, point)
+			}
+
+			vis.DeleteNode(point)
+		}
+	}
+}
+
+// passable checks if vertex is corner of visible square and it could be passed by connectivity
+func (l *lattice) passable(c cell) bool {
+	visible := l.visible(c) || l.visible(cell{x: c.x - 1, y: c.y}) ||
+		l.visible(cell{x: c.x - 1, y: c.y - 1}) || l.visible(cell{x: c.x, y: c.y - 1})
+
+	return visible && (l.connectivity.cornerCutting() || !isPinch(c, l.visible))
+}
+
+// vertex return vertex of visible square by its cell
+func (l *lattice) vertex(c cell) (geom.Vector2, bool) {
+	if c.x < 0 || c.y < 0 || c.x > l.width || c.y > l.height {
+		return geom.Vector2{}, false
+	}
+
+	id := l.vertexID(c)
+	return l.vertices[id], l.present[id]
+}
+
+func (l *lattice) vertexID(c cell) int32 {
+	return c.y*(l.width+1) + c.x
+}
+
+func (l *lattice) vertexCell(id int32) cell {
+	return cell{x: id % (l.width + 1), y: id / (l.width + 1)}
+}
+
+// locate return cells of visible squares which contain point, point on square side is contained by both squares
+// Squares around cell of point are checked by their bounds, so accumulated error of squares positions doesn't matter
+func (l *lattice) locate(point geom.Vector2) []cell {
+	var (
+		at    = l.cellAt(point)
+		cells = make([]cell, 0, 4)
+	)
+
+	for x := at.x - 1; x <= at.x+1; x++ {
+		for y := at.y - 1; y <= at.y+1; y++ {
+			c := cell{x: x, y: y}
+			if idx, ok := l.square(c); ok && l.visSquares[idx].isPointInsideSquare(point) {
+				cells = append(cells, c)
+			}
+		}
+	}
+
+	return cells
+}
+
+// cellAt return cell of square which could contain point, cell could be out of lattice
+func (l *lattice) cellAt(point geom.Vector2) cell {
+	return cell{
+		x: int32(math.Floor(float64((point.X - l.origin.X) / l.squareSize))),
+		y: int32(math.Floor(float64((point.Y - l.origin.Y) / l.squareSize))),
+	}
+}
+
+// squaresIn return indexes of visible squares which cells intersect bounds
+func (l *lattice) squaresIn(lo, hi geom.Vector2) []int32 {
+	var (
+		from    = l.cellAt(lo)
+		to      = l.cellAt(hi)
+		squares = make([]int32, 0)
+	)
+
+	for x := max(from.x, 0); x <= min(to.x, l.width-1); x++ {
+		for y := max(from.y, 0); y <= min(to.y, l.height-1); y++ {
+			if idx, ok := l.square(cell{x: x, y: y}); ok {
+				squares = append(squares, idx)
+			}
+		}
+	}
+
+	return squares
+}
+
+// closestPoint return the closest vertex or center of visible square, squares are checked by rings of cells around point
+// until ring is farther than found point. Point out of lattice starts from the nearest cell of lattice
+func (l *lattice) closestPoint(point geom.Vector2) (geom.Vector2, bool) {
+	var (
+		at = l.cellAt(point)
+		c  = cell{x: min(max(at.x, 0), l.width-1), y: min(max(at.y, 0), l.height-1)}
+
+		closest      = float32(math.MaxFloat32)
+		closestPoint = geom.Vector2{}
+	)
+
+	check := func(c cell) {
+		idx, ok := l.square(c)
+		if !ok {
+			return
+		}
+
+		square := l.visSquares[idx]
+		for _, v := range []geom.Vector2{square.A, square.B, square.C, square.D, square.Center} {
+			if dist := geom.Distance(point, v); dist < closest {
+				closest, closestPoint = dist, v
+			}
+		}
+	}
+
+	for r := int32(0); r <= max(l.width, l.height); r++ {
+		// squares of ring are at least r-1 squares far from cell of point (or its projection to lattice)
+		if float32(r-1)*l.squareSize > closest {
+			break
+		}
+
+		for x := c.x - r; x <= c.x+r; x++ {
+			check(cell{x: x, y: c.y - r})
+			if r > 0 {
+				check(cell{x: x, y: c.y + r})
+			}
+		}
+
+		for y := c.y - r + 1; y <= c.y+r-1; y++ {
+			check(cell{x: c.x - r, y: y})
+			check(cell{x: c.x + r, y: y})
+		}
+	}
+
+	return closestPoint, closest != math.MaxFloat32
+}
+
+// corners return vertex cells of square cell
+func corners(c cell) [4]cell {
+	return [4]cell{c, {x: c.x + 1, y: c.y}, {x: c.x + 1, y: c.y + 1}, {x: c.x, y: c.y + 1}}
+}
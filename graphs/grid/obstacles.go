@@ -0,0 +1,246 @@
+package grid
+
+import (
+	"slices"
+
+	"github.com/bolom009/geom"
+	"github.com/bolom009/pathfind/graphs/internal"
+	"github.com/bolom009/pathfind/mesh"
+)
+
+// AddObstacles blocks visible squares which obstacles overlap (inflated by positive offset) until obstacles are removed
+// Obstacles which are added before Generate block squares of generated grid. Only vertices, edges, clusters
+// and jump points around changed squares are rebuilt, queries keep reading previous data until update is published
+func (g *Grid) AddObstacles(obstacles ...*mesh.Hole) []uint32 {
+	if len(obstacles) == 0 {
+		return nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var (
+		state   = g.state.Load()
+		blocked = g.cloneBlocked(state)
+		ids     = make([]uint32, len(obstacles))
+		changed []int32
+	)
+
+	for i, obstacle := range obstacles {
+		ids[i] = g.obstaclePool.New(obstacle)
+		if g.lattice == nil {
+			continue
+		}
+
+		squares := g.lattice.obstacleSquares(g.visSquares, obstacle)
+		g.obstacleSquares[ids[i]] = squares
+		changed = blockSquares(blocked, squares, changed)
+	}
+
+	// counts of squares which were blocked by other obstacles are changed too
+	next := state.update(g.visSquares, blocked, changed, g.Cost)
+	next.raycastObstacles = g.raycastObstacles()
+	g.state.Store(next)
+	if len(changed) > 0 {
+		g.recordSquares(changed)
+	}
+
+	return ids
+}
+
+// RemoveObstacles unblocks squares which aren't blocked by other obstacles, unknown ids are ignored
+func (g *Grid) RemoveObstacles(ids ...uint32) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var (
+		state   = g.state.Load()
+		blocked = g.cloneBlocked(state)
+		changed []int32
+	)
+
+	for _, id := range ids {
+		for _, square := range g.obstacleSquares[id] {
+			if blocked[square]--; blocked[square] == 0 {
+				changed = append(changed, square)
+			}
+		}
+
+		delete(g.obstacleSquares, id)
+		g.obstaclePool.Delete(id)
+	}
+
+	next := state.update(g.visSquares, blocked, changed, g.Cost)
+	next.raycastObstacles = g.raycastObstacles()
+	g.state.Store(next)
+	if len(changed) > 0 {
+		g.changes.Record(internal.Change[bounds]{Full: true})
+	}
+}
+
+// raycastObstacles return copy of extra obstacles which block raycasts, writer must hold mu
+func (g *Grid) raycastObstacles() []*mesh.Hole {
+	if !g.obstacleRaycasts {
+		return nil
+	}
+
+	obstacles := make([]*mesh.Hole, 0, len(g.obstaclePool.GetList()))
+	for _, obstacle := range g.obstaclePool.GetList() {
+		if !obstacle.Viewable() {
+			obstacles = append(obstacles, obstacle)
+		}
+	}
+
+	return obstacles
+}
+
+// cloneBlocked return copy of obstacles counts of visible squares which could be changed by writer
+func (g *Grid) cloneBlocked(state *snapshot) []uint16 {
+	if state.blocked == nil {
+		return make([]uint16, len(g.visSquares))
+	}
+
+	return slices.Clone(state.blocked)
+}
+
+// blockSquares increase obstacles counts of squares and append squares which become blocked to changed
+func blockSquares(blocked []uint16, squares, changed []int32) []int32 {
+	for _, square := range squares {
+		if blocked[square]++; blocked[square] == 1 {
+			changed = append(changed, square)
+		}
+	}
+
+	return changed
+}
+
+// isBlocked checks if visible square is blocked by extra obstacles
+func (s *snapshot) isBlocked(square int) bool {
+	return s.blocked != nil && s.blocked[square] > 0
+}
+
+// update return copy of snapshot where changed squares are blocked or unblocked by obstacles counts
+// Vertices and edges are rebuilt only around changed squares, neighbours slices of other vertices are shared
+func (s *snapshot) update(visSquares []Square, blocked []uint16, changed []int32, cost func(a, b geom.Vector2) float32) *snapshot {
+	if len(changed) == 0 {
+		next := *s
+		next.blocked = blocked
+		return &next
+	}
+
+	var (
+		l        = s.lattice.clone()
+		vis      = s.visibilityGraph.Copy()
+		cells    = make([]cell, len(changed))
+		vertices = make(map[int32]struct{}, 4*len(changed))
+		reach    = int32(0)
+	)
+
+	if !l.connectivity.cornerCutting() {
+		reach = 1
+	}
+
+	for i, square := range changed {
+		c := l.cellOf(visSquares[square].A)
+		l.squares[c.y*l.width+c.x] = square
+		if blocked[square] > 0 {
+			l.squares[c.y*l.width+c.x] = -1
+		}
+
+		// without corner cutting links of vertices around corners are changed too, because corners could become pinches
+		for x := max(c.x-reach, 0); x <= min(c.x+1+reach, l.width); x++ {
+			for y := max(c.y-reach, 0); y <= min(c.y+1+reach, l.height); y++ {
+				vertices[l.vertexID(cell{x: x, y: y})] = struct{}{}
+			}
+		}
+
+		cells[i] = c
+	}
+
+	for id := range vertices {
+		var (
+			c     = l.vertexCell(id)
+			point = l.vertices[id]
+		)
+
+		l.present[id] = l.passable(c)
+		if !l.present[id] {
+			// vertex around changed squares could be never present, so its position isn't known
+			if s.lattice.present[id] {
+				vis.DeleteNode(point)
+			}
+			continue
+		}
+
+		neighbours := make([]geom.Vector2, 0, len(directions))
+		for d, dir := range directions {
+			if canMove(c, d, l.connectivity, l.visible) {
+				neighbours = append(neighbours, l.vertices[l.vertexID(cell{x: c.x + dir.x, y: c.y + dir.y})])
+			}
+		}
+
+		vis[point] = slices.Clip(neighbours)
+	}
+
+	next := &snapshot{visibilityGraph: vis, lattice: l, blocked: blocked}
+	if s.hierarchy != nil {
+		next.hierarchy = s.hierarchy.update(l, vis, cells, cost)
+	}
+	if s.jumpPoints != nil {
+		next.jumpPoints = s.jumpPoints.update(l, cells)
+	}
+
+	return next
+}
+
+// obstacleSquares return indexes of visible squares which obstacle overlaps, obstacle is inflated by its positive offset
+func (l *lattice) obstacleSquares(visSquares []Square, obstacle *mesh.Hole) []int32 {
+	polygon := obstacle.Points()
+	if len(polygon) == 0 {
+		return nil
+	}
+
+	var (
+		radius = max(obstacle.Offset(), 0)
+		lo, hi = polygon[0], polygon[0]
+	)
+
+	for _, point := range polygon {
+		lo.X, lo.Y = min(lo.X, point.X), min(lo.Y, point.Y)
+		hi.X, hi.Y = max(hi.X, point.X), max(hi.Y, point.Y)
+	}
+
+	squares := l.squaresIn(geom.Vector2{X: lo.X - radius, Y: lo.Y - radius}, geom.Vector2{X: hi.X + radius, Y: hi.Y + radius})
+	return slices.DeleteFunc(squares, func(square int32) bool {
+		return !isSquareBlocked(polygon, visSquares[square], radius)
+	})
+}
+
+// isSquareBlocked checks if obstacle polygon overlaps square or is closer than radius to it
+// square is shrunk a bit, so obstacle which only touches its sides doesn't block it
+func isSquareBlocked(polygon []geom.Vector2, square Square, radius float32) bool {
+	if pointInPolygon(square.Center, polygon) {
+		return true
+	}
+
+	var (
+		eps     = (square.C.X - square.A.X) * 1e-3
+		lo      = geom.Vector2{X: square.A.X + eps, Y: square.A.Y + eps}
+		hi      = geom.Vector2{X: square.C.X - eps, Y: square.C.Y - eps}
+		corners = [4]geom.Vector2{lo, {X: hi.X, Y: lo.Y}, hi, {X: lo.X, Y: hi.Y}}
+	)
+
+	for _, point := range polygon {
+		if point.X > lo.X && point.X < hi.X && point.Y > lo.Y && point.Y < hi.Y {
+			return true
+		}
+	}
+
+	for i := range corners {
+		if isSegmentBlocked(polygon, corners[i], corners[(i+1)%len(corners)], radius) {
+			return true
+		}
+	}
+
+	return false
+}
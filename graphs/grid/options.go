@@ -3,13 +3,17 @@ package grid
 import (
 	"github.com/bolom009/astar"
 	"github.com/bolom009/geom"
+	"github.com/bolom009/pathfind/graphs"
+	"github.com/bolom009/pathfind/mesh"
 )
 
 type option func(g *Grid)
 
+// WithCostFunc set cost of segments, it's used as estimate of path cost too
 func WithCostFunc(costFunc astar.CostFunc[geom.Vector2]) option {
 	return func(g *Grid) {
 		g.costFunc = costFunc
+		g.estimateFunc = costFunc
 	}
 }
 
@@ -18,3 +22,59 @@ func WithOffset(offset geom.Vector2) option {
 		g.offset = offset
 	}
 }
+
+// WithProgress set callback to report progress of Generate
+func WithProgress(progressFunc graphs.ProgressFunc) option {
+	return func(g *Grid) {
+		g.progressFunc = progressFunc
+	}
+}
+
+// WithAreas add cost areas (mesh.NewArea) to grid, later areas overlap earlier ones
+func WithAreas(areas ...*mesh.Hole) option {
+	return func(g *Grid) {
+		g.areaHoles = append(g.areaHoles, areas...)
+	}
+}
+
+// WithAreaCosts set cost multipliers of area types, cost of segment is its length multiplied by cost of area
+// Multipliers below mesh.MinAreaCost are clamped to it
+func WithAreaCosts(costs mesh.AreaCosts) option {
+	return func(g *Grid) {
+		g.areaCosts = costs
+	}
+}
+
+// WithHierarchy enable hierarchical search (HPA*) over clusters of clusterSize x clusterSize squares
+// Abstract graph of cluster entrances is built by Generate, so long paths expand only few nodes.
+// Cluster size less than 2 disables hierarchy
+func WithHierarchy(clusterSize int) option {
+	return func(g *Grid) {
+		g.clusterSize = int32(clusterSize)
+	}
+}
+
+// WithJumpPointSearch enable Jump Point Search for all queries, it finds the same shortest paths as visibility graph search
+// with far fewer expanded vertices. It's used only if areas don't change costs, costFunc measures distance
+// and diagonals are linked (not Connectivity4)
+func WithJumpPointSearch(jumpPointSearch bool) option {
+	return func(g *Grid) {
+		g.jumpPointSearch = jumpPointSearch
+	}
+}
+
+// WithObstacleRaycasts enable raycasts against extra obstacles (AddObstacles) which aren't viewable,
+// by default only polygon and holes are checked like by recast graph
+func WithObstacleRaycasts(obstacleRaycasts bool) option {
+	return func(g *Grid) {
+		g.obstacleRaycasts = obstacleRaycasts
+	}
+}
+
+// WithConnectivity set rule of moves between vertices of squares, Connectivity8 is default.
+// Connectivity4 links only sides, Connectivity8NoCornerCutting doesn't let path slip between two diagonal blocked squares
+func WithConnectivity(connectivity Connectivity) option {
+	return func(g *Grid) {
+		g.connectivity = connectivity
+	}
+}
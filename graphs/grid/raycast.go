@@ -0,0 +1,241 @@
+package grid
+
+import (
+	"math"
+
+	"github.com/bolom009/geom"
+	"github.com/bolom009/pathfind/mesh"
+)
+
+// raycaster is index of outline edges (polygon and holes or blocked cells) by cells of square size,
+// segment is tested only against edges of cells which it goes through
+type raycaster struct {
+	origin   geom.Vector2
+	max      geom.Vector2
+	cellSize float32
+	width    int32
+	height   int32
+	cells    [][]int32
+	edges    [][2]geom.Vector2
+}
+
+func newRaycaster(edges [][2]geom.Vector2, cellSize float32) *raycaster {
+	if len(edges) == 0 || cellSize <= 0 {
+		return nil
+	}
+
+	r := &raycaster{origin: edges[0][0], max: edges[0][0], cellSize: cellSize, edges: edges}
+	for _, edge := range edges {
+		for _, point := range edge {
+			r.origin.X, r.origin.Y = min(r.origin.X, point.X), min(r.origin.Y, point.Y)
+			r.max.X, r.max.Y = max(r.max.X, point.X), max(r.max.Y, point.Y)
+		}
+	}
+
+	r.width = int32(math.Floor(float64((r.max.X-r.origin.X)/cellSize))) + 1
+	r.height = int32(math.Floor(float64((r.max.Y-r.origin.Y)/cellSize))) + 1
+	r.cells = make([][]int32, r.width*r.height)
+	for i, edge := range r.edges {
+		r.walk(edge[0], edge[1], func(c cell) bool {
+			idx := c.y*r.width + c.x
+			r.cells[idx] = append(r.cells[idx], int32(i))
+			return false
+		})
+	}
+
+	return r
+}
+
+// outlineEdges return edges of closed outlines
+func outlineEdges(outlines ...[]geom.Vector2) [][2]geom.Vector2 {
+	edges := make([][2]geom.Vector2, 0)
+	for _, outline := range outlines {
+		for i, point := range outline {
+			edges = append(edges, [2]geom.Vector2{point, outline[(i+1)%len(outline)]})
+		}
+	}
+
+	return edges
+}
+
+// isHit checks if segment crosses or touches some edge, segment out of bounds of outlines isn't hit
+func (r *raycaster) isHit(start, end geom.Vector2) bool {
+	// cells are walked along part of segment inside bounds, but edges are tested by segment itself
+	from, to, ok := clipSegment(start, end, r.origin, r.max)
+	if !ok {
+		return false
+	}
+
+	return r.walk(from, to, func(c cell) bool {
+		for _, i := range r.cells[c.y*r.width+c.x] {
+			if lineSegmentIntersection(start, end, r.edges[i][0], r.edges[i][1]) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// walk calls visit for cells which segment goes through (supercover) until visit returns true
+// Cells which contain ends of segment on their sides and cells around corners which segment goes through are visited too,
+// so segments which cross or touch each other always have common cell
+func (r *raycaster) walk(a, b geom.Vector2, visit func(c cell) bool) bool {
+	var (
+		x0, y0 = float64((a.X - r.origin.X) / r.cellSize), float64((a.Y - r.origin.Y) / r.cellSize)
+		x1, y1 = float64((b.X - r.origin.X) / r.cellSize), float64((b.Y - r.origin.Y) / r.cellSize)
+		c      = cell{x: int32(math.Floor(x0)), y: int32(math.Floor(y0))}
+		last   = cell{x: int32(math.Floor(x1)), y: int32(math.Floor(y1))}
+		step   = cell{x: direction(x1 - x0), y: direction(y1 - y0)}
+
+		tMaxX, tDeltaX = math.Inf(1), math.Inf(1)
+		tMaxY, tDeltaY = math.Inf(1), math.Inf(1)
+	)
+
+	try := func(c cell) bool {
+		return c.x >= 0 && c.y >= 0 && c.x < r.width && c.y < r.height && visit(c)
+	}
+
+	if x1 != x0 {
+		tDeltaX = math.Abs(1 / (x1 - x0))
+		tMaxX = (math.Floor(x0) + float64(max(step.x, 0)) - x0) / (x1 - x0)
+	}
+	if y1 != y0 {
+		tDeltaY = math.Abs(1 / (y1 - y0))
+		tMaxY = (math.Floor(y0) + float64(max(step.y, 0)) - y0) / (y1 - y0)
+	}
+
+	for _, p := range [2][2]float64{{x0, y0}, {x1, y1}} {
+		for _, side := range sideCells(p[0], p[1]) {
+			if try(side) {
+				return true
+			}
+		}
+	}
+
+	for n := abs(last.x-c.x) + abs(last.y-c.y); n >= 0; n-- {
+		if try(c) {
+			return true
+		}
+
+		if c == last {
+			return false
+		}
+
+		switch {
+		case math.Abs(tMaxX-tMaxY) < 1e-9:
+			// segment goes through corner, cells on both sides of it are visited
+			if try(cell{x: c.x + step.x, y: c.y}) || try(cell{x: c.x, y: c.y + step.y}) {
+				return true
+			}
+
+			c.x, c.y = c.x+step.x, c.y+step.y
+			tMaxX, tMaxY = tMaxX+tDeltaX, tMaxY+tDeltaY
+			n--
+		case tMaxX < tMaxY:
+			c.x += step.x
+			tMaxX += tDeltaX
+		default:
+			c.y += step.y
+			tMaxY += tDeltaY
+		}
+	}
+
+	return false
+}
+
+// sideCells return previous cells which contain point on their sides or corner
+func sideCells(x, y float64) []cell {
+	cells := make([]cell, 0, 3)
+	fx, fy := math.Floor(x), math.Floor(y)
+	if x == fx {
+		cells = append(cells, cell{x: int32(fx) - 1, y: int32(fy)})
+	}
+	if y == fy {
+		cells = append(cells, cell{x: int32(fx), y: int32(fy) - 1})
+	}
+	if x == fx && y == fy {
+		cells = append(cells, cell{x: int32(fx) - 1, y: int32(fy) - 1})
+	}
+
+	return cells
+}
+
+// clipSegment return part of segment inside bounds (Liang-Barsky)
+func clipSegment(a, b, lo, hi geom.Vector2) (geom.Vector2, geom.Vector2, bool) {
+	var (
+		t0, t1 = float32(0), float32(1)
+		axes   = [2][4]float32{{a.X, b.X - a.X, lo.X, hi.X}, {a.Y, b.Y - a.Y, lo.Y, hi.Y}}
+	)
+
+	for _, axis := range axes {
+		p, d, from, to := axis[0], axis[1], axis[2], axis[3]
+		if d == 0 {
+			if p < from || p > to {
+				return a, b, false
+			}
+
+			continue
+		}
+
+		ta, tb := (from-p)/d, (to-p)/d
+		if ta > tb {
+			ta, tb = tb, ta
+		}
+
+		t0, t1 = max(t0, ta), min(t1, tb)
+		if t0 > t1 {
+			return a, b, false
+		}
+	}
+
+	return a.Lerp(b, t0), a.Lerp(b, t1), true
+}
+
+// isObstacleHit checks if segment crosses or touches outline of obstacle
+func isObstacleHit(obstacle *mesh.Hole, start, end geom.Vector2) bool {
+	points := obstacle.Points()
+	for i := range points {
+		if lineSegmentIntersection(start, end, points[i], points[(i+1)%len(points)]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lineSegmentIntersection checks if two line segments intersect, parallel segments don't intersect
+func lineSegmentIntersection(p1, p2, p3, p4 geom.Vector2) bool {
+	s1 := p4.Y - p3.Y
+	s2 := p2.X - p1.X
+	s3 := p4.X - p3.X
+	s4 := p2.Y - p1.Y
+
+	denom := s1*s2 - s3*s4
+	if denom == 0 {
+		return false
+	}
+
+	s5 := p1.Y - p3.Y
+	s6 := p1.X - p3.X
+
+	ua := (s3*s5 - s1*s6) / denom
+	ub := (s2*s5 - s4*s6) / denom
+	return ua >= 0 && ua <= 1 && ub >= 0 && ub <= 1
+}
+
+// direction return sign of coordinate change
+func direction(d float64) int32 {
+	switch {
+	case d > 0:
+		return 1
+	case d < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
+func abs(v int32) int32 {
+	return max(v, -v)
+}
@@ -0,0 +1,114 @@
+package grid
+
+import (
+	"math"
+	"slices"
+
+	"github.com/bolom009/geom"
+	"github.com/bolom009/pathfind/graphs"
+)
+
+// LineOfSight return check of straight moves over squares of query, segment is walkable if it goes only through
+// (or along sides of) visible squares of allowed areas which aren't blocked by extra obstacles
+// and isn't closer than agent radius to obstacles of navOpts. Segment doesn't pass vertex between
+// two diagonal blocked squares if connectivity doesn't cut corners.
+// This method makes Grid implement the graphs.SightChecker interface.
+func (g *Grid) LineOfSight(navOpts *graphs.NavOpts) func(a, b geom.Vector2) bool {
+	l := g.state.Load().lattice
+	if l == nil {
+		return func(a, b geom.Vector2) bool {
+			return !g.IsRaycastHit(a, b)
+		}
+	}
+
+	allowed := func(square int32) bool {
+		return navOpts.AreaAllowed(l.visSquares[square].Area)
+	}
+
+	return func(a, b geom.Vector2) bool {
+		if !l.isSegmentWalkable(a, b, allowed) {
+			return false
+		}
+
+		if navOpts == nil {
+			return true
+		}
+
+		for _, obstacle := range navOpts.Obstacles {
+			if isSegmentBlocked(obstacle.GetPolygon(), a, b, navOpts.AgentRadius) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// isSegmentWalkable checks if segment goes only through visible squares or along their sides
+// Segment is split where it crosses lines of squares, so each part lies inside one square or on its side
+func (l *lattice) isSegmentWalkable(a, b geom.Vector2, allowed func(square int32) bool) bool {
+	var (
+		from   = [2]float64{float64((a.X - l.origin.X) / l.squareSize), float64((a.Y - l.origin.Y) / l.squareSize)}
+		to     = [2]float64{float64((b.X - l.origin.X) / l.squareSize), float64((b.Y - l.origin.Y) / l.squareSize)}
+		params = []float64{0, 1}
+	)
+
+	for axis := range from {
+		if from[axis] == to[axis] {
+			continue
+		}
+
+		lo, hi := min(from[axis], to[axis]), max(from[axis], to[axis])
+		for line := math.Floor(lo) + 1; line < hi; line++ {
+			params = append(params, (line-from[axis])/(to[axis]-from[axis]))
+		}
+	}
+
+	slices.Sort(params)
+
+	at := func(t float64) (float64, float64) {
+		return from[0] + (to[0]-from[0])*t, from[1] + (to[1]-from[1])*t
+	}
+
+	for i := 0; i < len(params)-1; i++ {
+		// vertex which segment goes through between two diagonal blocked squares isn't present
+		if i > 0 && !l.connectivity.cornerCutting() && !l.isPassed(at(params[i])) {
+			return false
+		}
+
+		if params[i+1]-params[i] < 1e-9 {
+			continue
+		}
+
+		if x, y := at((params[i] + params[i+1]) / 2); !l.isWalkable(x, y, allowed) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isWalkable checks if some visible square of allowed area contains point in lattice units
+func (l *lattice) isWalkable(x, y float64, allowed func(square int32) bool) bool {
+	xs, ys := cellRange(x), cellRange(y)
+	for cx := xs[0]; cx <= xs[1]; cx++ {
+		for cy := ys[0]; cy <= ys[1]; cy++ {
+			if square, ok := l.square(cell{x: cx, y: cy}); ok && allowed(square) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isPassed checks if point in lattice units isn't vertex or it's present vertex
+func (l *lattice) isPassed(x, y float64) bool {
+	xs, ys := cellRange(x), cellRange(y)
+	if xs[0] == xs[1] || ys[0] == ys[1] {
+		return true
+	}
+
+	_, ok := l.vertex(cell{x: xs[1], y: ys[1]})
+	return ok
+}
@@ -1,6 +1,9 @@
 package grid
 
-import "github.com/bolom009/geom"
+import (
+	"github.com/bolom009/geom"
+	"github.com/bolom009/pathfind/mesh"
+)
 
 // Edge represent square edge
 type Edge struct {
@@ -8,8 +11,10 @@ type Edge struct {
 }
 
 // Square represent square with four points, also include info about each point if it's inside polygon
+// Area is type of cost area which contains square center
 type Square struct {
 	A, B, C, D, Center geom.Vector2
+	Area               mesh.AreaType
 	// represent each point inside polygon
 	isA, isB, isC, isD, isCenter bool
 }
@@ -24,6 +29,15 @@ func (s *Square) Edges() []Edge {
 	}
 }
 
+// graphEdges return sides and diagonals of square which are linked in visibility graph
+// sides shared by neighbour squares have the same points order
+func (s *Square) graphEdges() [6]Edge {
+	return [6]Edge{
+		{A: s.A, B: s.B}, {A: s.A, B: s.D}, {A: s.B, B: s.C},
+		{A: s.D, B: s.C}, {A: s.A, B: s.C}, {A: s.B, B: s.D},
+	}
+}
+
 func (s *Square) isInside() bool {
 	return s.isA && s.isB && s.isC && s.isD && s.isCenter
 }
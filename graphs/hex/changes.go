@@ -0,0 +1,86 @@
+package hex
+
+import (
+	"github.com/bolom009/geom"
+	"github.com/bolom009/pathfind/graphs/internal"
+)
+
+// bounds is box of hexes blocked by change
+type bounds struct {
+	min, max geom.Vector2
+}
+
+// Version return count of changes of graph data (Generate and obstacles updates)
+// This method makes Grid implement the graphs.ChangeTracker interface.
+func (g *Grid) Version() uint64 {
+	return g.changes.Version()
+}
+
+// PathChanged checks if path which was found at version could be changed by later updates
+// Path is changed by hexes blocked later which bounds it crosses and by any removed obstacle,
+// because new shorter path could go anywhere.
+// This method makes Grid implement the graphs.ChangeTracker interface.
+func (g *Grid) PathChanged(path []geom.Vector2, version uint64) bool {
+	return g.changes.PathChanged(version, func(b bounds) bool {
+		return b.crossedBy(path)
+	})
+}
+
+// recordHexes publish change of blocked hexes bounds, paths which don't cross them stay the shortest,
+// because blocked hexes only remove their centers and links
+func (g *Grid) recordHexes(hexes []int32) {
+	box := bounds{min: g.hexes[hexes[0]].Center, max: g.hexes[hexes[0]].Center}
+	for _, hex := range hexes {
+		for _, corner := range g.hexes[hex].Corners {
+			box.min.X, box.min.Y = min(box.min.X, corner.X), min(box.min.Y, corner.Y)
+			box.max.X, box.max.Y = max(box.max.X, corner.X), max(box.max.Y, corner.Y)
+		}
+	}
+
+	g.changes.Record(internal.Change[bounds]{Bounds: box})
+}
+
+// crossedBy checks if some segment of path touches bounds
+func (c bounds) crossedBy(path []geom.Vector2) bool {
+	if len(path) == 1 {
+		return c.crossesSegment(path[0], path[0])
+	}
+
+	for i := 0; i < len(path)-1; i++ {
+		if c.crossesSegment(path[i], path[i+1]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// crossesSegment checks if segment touches bounds (Liang-Barsky clipping)
+func (c bounds) crossesSegment(a, b geom.Vector2) bool {
+	var (
+		t0, t1 = float32(0), float32(1)
+		axes   = [2][4]float32{{a.X, b.X - a.X, c.min.X, c.max.X}, {a.Y, b.Y - a.Y, c.min.Y, c.max.Y}}
+	)
+
+	for _, axis := range axes {
+		p, d, from, to := axis[0], axis[1], axis[2], axis[3]
+		if d == 0 {
+			if p < from || p > to {
+				return false
+			}
+
+			continue
+		}
+
+		ta, tb := (from-p)/d, (to-p)/d
+		if ta > tb {
+			ta, tb = tb, ta
+		}
+
+		if t0, t1 = max(t0, ta), min(t1, tb); t0 > t1 {
+			return false
+		}
+	}
+
+	return true
+}
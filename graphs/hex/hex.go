@@ -0,0 +1,710 @@
+package hex
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bolom009/astar"
+	"github.com/bolom009/geom"
+	"github.com/bolom009/pathfind/graphs"
+	"github.com/bolom009/pathfind/graphs/internal"
+	"github.com/bolom009/pathfind/obstacles"
+)
+
+// Grid is navigation graph based on hexes of polygon, path goes through centers of neighbour hexes
+// Hexes blocked by extra obstacles are published by atomic swap of snapshot like in grid.Grid,
+// so queries don't wait for obstacle updates and updates are serialized by mutex
+type Grid struct {
+	polygon      []geom.Vector2
+	holes        [][]geom.Vector2
+	layout       layout
+	costFunc     astar.CostFunc[geom.Vector2]
+	estimateFunc astar.CostFunc[geom.Vector2]
+	progressFunc graphs.ProgressFunc
+
+	// hexes are visible hexes (inside polygon and out of holes), index is dense map of their axial coordinates
+	hexes []Hex
+	index *index
+	edges [][2]geom.Vector2
+
+	state atomic.Pointer[snapshot]
+	mu    sync.Mutex
+
+	// extra obstacles and visible hexes blocked by each obstacle
+	obstaclePool  *internal.ObstaclePool
+	obstacleHexes map[uint32][]int32
+
+	// changes is log of last updates which is checked by path caches
+	changes internal.Changes[bounds]
+}
+
+// snapshot is graph data which is read by queries, blocked is count of obstacles which block each visible hex
+// Visibility graph doesn't contain blocked hexes
+type snapshot struct {
+	visibilityGraph graphs.Graph[geom.Vector2]
+	blocked         []uint16
+}
+
+// index is dense row-major array of visible hexes indexes by axial coordinates (-1 for invisible hex)
+type index struct {
+	min    Axial
+	width  int32
+	height int32
+	hexes  []int32
+}
+
+// NewGrid create graph of hexes which cover polygon, size is distance from hex center to its corner
+func NewGrid(polygon []geom.Vector2, holes [][]geom.Vector2, size float32, options ...option) *Grid {
+	g := &Grid{
+		polygon:       polygon,
+		holes:         holes,
+		layout:        layout{size: size},
+		costFunc:      heuristicEvaluation,
+		obstaclePool:  internal.NewObstaclePool(30),
+		obstacleHexes: make(map[uint32][]int32),
+	}
+
+	if len(polygon) > 0 {
+		g.layout.origin = polygon[0]
+		for _, point := range polygon {
+			g.layout.origin.X, g.layout.origin.Y = min(g.layout.origin.X, point.X), min(g.layout.origin.Y, point.Y)
+		}
+	}
+
+	for _, option := range options {
+		option(g)
+	}
+
+	if g.estimateFunc == nil {
+		g.estimateFunc = g.hexEstimate
+	}
+
+	g.state.Store(&snapshot{visibilityGraph: make(graphs.Graph[geom.Vector2])})
+	return g
+}
+
+// Generate split polygon to hexes and build visibility graph, hexes of extra obstacles are blocked
+// ctx is checked between columns of hexes (nil ctx is context.Background()), on cancel previously generated data is kept
+func (g *Grid) Generate(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	hexes, idx, err := g.generateHexes(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		blocked       = make([]uint16, len(hexes))
+		ids           = g.obstaclePool.IDs()
+		obstacleHexes = make(map[uint32][]int32, len(ids))
+	)
+
+	g.hexes, g.index = hexes, idx
+	for i, obstacle := range g.obstaclePool.GetList() {
+		obstacleHexes[ids[i]] = g.blockedHexes(obstacle)
+		blockHexes(blocked, obstacleHexes[ids[i]], nil)
+	}
+
+	vis := make(graphs.Graph[geom.Vector2], len(hexes))
+	for i, hex := range hexes {
+		if blocked[i] == 0 {
+			vis[hex.Center] = g.links(hex, blocked)
+		}
+	}
+
+	g.obstacleHexes = obstacleHexes
+	g.edges = outlineEdges(append([][]geom.Vector2{g.polygon}, g.holes...)...)
+	g.state.Store(&snapshot{visibilityGraph: vis, blocked: blocked})
+	g.changes.Record(internal.Change[bounds]{Full: true})
+	g.reportProgress(1)
+
+	return nil
+}
+
+// generateHexes return visible hexes which center and corners are inside polygon and out of holes
+func (g *Grid) generateHexes(ctx context.Context) ([]Hex, *index, error) {
+	if len(g.polygon) == 0 {
+		return nil, nil, nil
+	}
+
+	lo, hi := g.polygon[0], g.polygon[0]
+	for _, point := range g.polygon {
+		lo.X, lo.Y = min(lo.X, point.X), min(lo.Y, point.Y)
+		hi.X, hi.Y = max(hi.X, point.X), max(hi.Y, point.Y)
+	}
+
+	var (
+		from, to = g.axialBounds(lo, hi)
+		idx      = &index{min: from, width: to.Q - from.Q + 1, height: to.R - from.R + 1}
+		hexes    = make([]Hex, 0)
+	)
+
+	idx.hexes = make([]int32, idx.width*idx.height)
+	for q := from.Q; q <= to.Q; q++ {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		g.reportProgress(0.9 * float32(q-from.Q) / float32(idx.width))
+		for r := from.R; r <= to.R; r++ {
+			var (
+				a      = Axial{Q: q, R: r}
+				center = g.layout.center(a)
+				hex    = Hex{Axial: a, Center: center, Corners: g.layout.corners(center)}
+				inside = g.isInsidePolygonWithHoles(center)
+			)
+
+			for _, corner := range hex.Corners {
+				inside = inside && g.isInsidePolygonWithHoles(corner)
+			}
+
+			idx.hexes[idx.offset(a)] = -1
+			if inside {
+				idx.hexes[idx.offset(a)] = int32(len(hexes))
+				hexes = append(hexes, hex)
+			}
+		}
+	}
+
+	return hexes, idx, nil
+}
+
+// axialBounds return range of axial coordinates of hexes which could overlap bounds
+func (g *Grid) axialBounds(lo, hi geom.Vector2) (Axial, Axial) {
+	var (
+		corners  = [4]geom.Vector2{lo, {X: hi.X, Y: lo.Y}, hi, {X: lo.X, Y: hi.Y}}
+		from, to = g.layout.axialOf(lo), g.layout.axialOf(lo)
+	)
+
+	for _, corner := range corners {
+		a := g.layout.axialOf(corner)
+		from.Q, from.R = min(from.Q, a.Q), min(from.R, a.R)
+		to.Q, to.R = max(to.Q, a.Q), max(to.R, a.R)
+	}
+
+	return Axial{Q: from.Q - 1, R: from.R - 1}, Axial{Q: to.Q + 1, R: to.R + 1}
+}
+
+// links return centers of visible neighbour hexes which aren't blocked
+func (g *Grid) links(hex Hex, blocked []uint16) []geom.Vector2 {
+	neighbours := make([]geom.Vector2, 0, len(directions))
+	for _, a := range hex.Neighbours() {
+		if i, ok := g.index.hex(a); ok && blocked[i] == 0 {
+			neighbours = append(neighbours, g.hexes[i].Center)
+		}
+	}
+
+	return neighbours
+}
+
+// hexesIn return indexes of visible hexes which could overlap bounds
+func (g *Grid) hexesIn(lo, hi geom.Vector2) []int32 {
+	if g.index == nil {
+		return nil
+	}
+
+	var (
+		from, to = g.axialBounds(lo, hi)
+		hexes    = make([]int32, 0)
+	)
+
+	for q := max(from.Q, g.index.min.Q); q <= min(to.Q, g.index.min.Q+g.index.width-1); q++ {
+		for r := max(from.R, g.index.min.R); r <= min(to.R, g.index.min.R+g.index.height-1); r++ {
+			if i, ok := g.index.hex(Axial{Q: q, R: r}); ok {
+				hexes = append(hexes, i)
+			}
+		}
+	}
+
+	return hexes
+}
+
+func (g *Grid) reportProgress(progress float32) {
+	if g.progressFunc != nil {
+		g.progressFunc(progress)
+	}
+}
+
+// AxialOf return axial coordinate of hex which contains point
+func (g *Grid) AxialOf(point geom.Vector2) Axial {
+	return g.layout.axialOf(point)
+}
+
+// Center return center of hex by its axial coordinate
+func (g *Grid) Center(a Axial) geom.Vector2 {
+	return g.layout.center(a)
+}
+
+// ContainsPoint checks if point is inside visible hex
+func (g *Grid) ContainsPoint(point geom.Vector2) bool {
+	_, ok := g.hexOf(point)
+	return ok
+}
+
+func (g *Grid) GetVisibility(navOpts *graphs.NavOpts) graphs.Graph[geom.Vector2] {
+	vis := g.state.Load().visibilityGraph.Copy()
+	if navOpts != nil && navOpts.Obstacles != nil {
+		g.updateGraphWithObstacles(vis, navOpts.Obstacles, navOpts.AgentRadius)
+	}
+
+	return vis
+}
+
+// AggregationGraph add start and dest points to existing pathfinder graph, they are linked with centers of their hexes
+func (g *Grid) AggregationGraph(start, dest geom.Vector2, navOpts *graphs.NavOpts) graphs.Graph[geom.Vector2] {
+	var (
+		state = g.state.Load()
+		vis   = state.visibilityGraph.Copy()
+	)
+
+	for _, point := range []geom.Vector2{start, dest} {
+		if i, ok := g.hexOf(point); ok && state.blocked[i] == 0 {
+			vis.LinkBoth(g.hexes[i].Center, point)
+		}
+	}
+
+	if navOpts != nil && navOpts.Obstacles != nil {
+		g.updateGraphWithObstacles(vis, navOpts.Obstacles, navOpts.AgentRadius, start, dest)
+	}
+
+	return vis
+}
+
+// GetClosestPoint return the closest center of visible hex which isn't blocked by extra obstacles
+// hexes are checked by rings around point until ring is farther than found center
+func (g *Grid) GetClosestPoint(point geom.Vector2) (geom.Vector2, bool) {
+	state := g.state.Load()
+	if g.index == nil {
+		return geom.Vector2{}, false
+	}
+
+	var (
+		closest      = float32(math.MaxFloat32)
+		closestPoint = geom.Vector2{}
+		center       = g.layout.axialOf(point)
+	)
+
+	check := func(i int32) {
+		if state.blocked[i] > 0 {
+			return
+		}
+
+		if dist := geom.Distance(point, g.hexes[i].Center); dist < closest {
+			closest, closestPoint = dist, g.hexes[i].Center
+		}
+	}
+
+	// point out of hexes range is checked with all hexes
+	if !g.index.contains(center) {
+		for i := range g.hexes {
+			check(int32(i))
+		}
+
+		return closestPoint, closest != math.MaxFloat32
+	}
+
+	for radius := int32(0); radius <= g.index.width+g.index.height; radius++ {
+		// centers of ring hexes are at least 1.5 sizes per ring far from center of point hex
+		if (1.5*float32(radius)-1)*g.layout.size > closest {
+			break
+		}
+
+		for _, a := range ring(center, radius) {
+			if i, ok := g.index.hex(a); ok {
+				check(i)
+			}
+		}
+	}
+
+	return closestPoint, closest != math.MaxFloat32
+}
+
+// IsRaycastHit checks if segment crosses or touches outline of polygon or holes
+func (g *Grid) IsRaycastHit(start, end geom.Vector2) bool {
+	for _, edge := range g.edges {
+		if lineSegmentIntersection(start, end, edge[0], edge[1]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (g *Grid) Cost(a, b geom.Vector2) float32 {
+	return g.costFunc(a, b)
+}
+
+// Estimate return count of moves between hexes of points multiplied by distance between centers of neighbour hexes
+// or costFunc if it's set, so it's never bigger than cost of path
+// This method makes Grid implement the graphs.Estimator interface.
+func (g *Grid) Estimate(a, b geom.Vector2) float32 {
+	return g.estimateFunc(a, b)
+}
+
+// hexEstimate return length of moves between centers of hexes which contain points
+func (g *Grid) hexEstimate(a, b geom.Vector2) float32 {
+	return float32(g.layout.axialOf(a).Distance(g.layout.axialOf(b))) * g.layout.spacing()
+}
+
+const (
+	scaleFactor        = 1e6
+	hashRnd            = 1099511628211
+	hashDefault uint64 = 14695981039346656037
+)
+
+func (g *Grid) HashIndex(v geom.Vector2) int64 {
+	qx := quantizeFloat(v.X)
+	qy := quantizeFloat(v.Y)
+
+	var hash = hashDefault
+	hash = (hash * hashRnd) ^ uint64(qx)
+	hash = (hash * hashRnd) ^ uint64(qy)
+
+	return int64(hash)
+}
+
+func quantizeFloat(f float32) int64 {
+	return int64(f * scaleFactor)
+}
+
+// Corridor return indexes of visible hexes which path goes through, path nodes are centers of neighbour hexes
+// This method makes Grid implement the graphs.PathDescriber interface.
+func (g *Grid) Corridor(path []geom.Vector2, _ *graphs.NavOpts) []int32 {
+	corridor := make([]int32, 0, len(path))
+	for _, point := range path {
+		if i, ok := g.hexOf(point); ok && (len(corridor) == 0 || corridor[len(corridor)-1] != i) {
+			corridor = append(corridor, i)
+		}
+	}
+
+	return corridor
+}
+
+// SnapPoint hex grid doesn't search out of area, so point is never snapped
+// This method makes Grid implement the graphs.PathDescriber interface.
+func (g *Grid) SnapPoint(point geom.Vector2, _ *graphs.NavOpts) (geom.Vector2, bool) {
+	return point, false
+}
+
+// Hexes return copied list of visible hexes, hexes blocked by extra obstacles are kept
+func (g *Grid) Hexes() []Hex {
+	hexes := make([]Hex, len(g.hexes))
+	copy(hexes, g.hexes)
+
+	return hexes
+}
+
+// hexOf return index of visible hex which contains point
+func (g *Grid) hexOf(point geom.Vector2) (int32, bool) {
+	if g.index == nil {
+		return -1, false
+	}
+
+	return g.index.hex(g.layout.axialOf(point))
+}
+
+// updateGraphWithObstacles delete centers & links of graph which are blocked by obstacles
+// if agentRadius is set, centers & links closer than agent radius to obstacle are blocked too
+// links of extra points (start, dest) are checked in addition to hex centers
+func (g *Grid) updateGraphWithObstacles(vis graphs.Graph[geom.Vector2], obstacles []obstacles.Obstacle, agentRadius float32, extra ...geom.Vector2) {
+	for _, point := range extra {
+		for _, obstacle := range obstacles {
+			for _, neighbour := range vis.Neighbours(point) {
+				if isSegmentBlocked(obstacle.GetPolygon(), point, neighbour, agentRadius) {
+					vis.DeleteNeighbour(point, neighbour)
+				}
+			}
+		}
+	}
+
+	for _, obstacle := range obstacles {
+		polygon := obstacle.GetPolygon()
+		if len(polygon) == 0 {
+			continue
+		}
+
+		// only hexes which centers or links could be closer than agent radius to obstacle are checked
+		var (
+			margin = agentRadius + 2*g.layout.spacing()
+			lo, hi = polygon[0], polygon[0]
+		)
+
+		for _, point := range polygon {
+			lo.X, lo.Y = min(lo.X, point.X), min(lo.Y, point.Y)
+			hi.X, hi.Y = max(hi.X, point.X), max(hi.Y, point.Y)
+		}
+
+		for _, i := range g.hexesIn(geom.Vector2{X: lo.X - margin, Y: lo.Y - margin}, geom.Vector2{X: hi.X + margin, Y: hi.Y + margin}) {
+			center := g.hexes[i].Center
+			if isPointBlocked(polygon, center, agentRadius) {
+				vis.DeleteNode(center)
+				continue
+			}
+
+			for _, neighbour := range vis.Neighbours(center) {
+				if isSegmentBlocked(polygon, center, neighbour, agentRadius) {
+					vis.DeleteNeighbour(center, neighbour)
+					vis.DeleteNeighbour(neighbour, center)
+				}
+			}
+		}
+	}
+}
+
+// hex return index of visible hex by its axial coordinate
+func (idx *index) hex(a Axial) (int32, bool) {
+	if !idx.contains(a) {
+		return -1, false
+	}
+
+	i := idx.hexes[idx.offset(a)]
+	return i, i >= 0
+}
+
+// contains checks if axial coordinate is inside range of index
+func (idx *index) contains(a Axial) bool {
+	return a.Q >= idx.min.Q && a.R >= idx.min.R && a.Q < idx.min.Q+idx.width && a.R < idx.min.R+idx.height
+}
+
+func (idx *index) offset(a Axial) int32 {
+	return (a.R-idx.min.R)*idx.width + a.Q - idx.min.Q
+}
+
+// ring return coordinates of hexes which are radius moves far from center
+func ring(center Axial, radius int32) []Axial {
+	if radius == 0 {
+		return []Axial{center}
+	}
+
+	var (
+		hexes = make([]Axial, 0, 6*radius)
+		a     = Axial{Q: center.Q + directions[4].Q*radius, R: center.R + directions[4].R*radius}
+	)
+
+	for _, dir := range directions {
+		for j := int32(0); j < radius; j++ {
+			hexes = append(hexes, a)
+			a = a.Add(dir)
+		}
+	}
+
+	return hexes
+}
+
+// outlineEdges return edges of closed outlines
+func outlineEdges(outlines ...[]geom.Vector2) [][2]geom.Vector2 {
+	edges := make([][2]geom.Vector2, 0)
+	for _, outline := range outlines {
+		for i, point := range outline {
+			edges = append(edges, [2]geom.Vector2{point, outline[(i+1)%len(outline)]})
+		}
+	}
+
+	return edges
+}
+
+// isInsidePolygonWithHoles checks if p is inside the outer polygon but not inside any holes
+func (g *Grid) isInsidePolygonWithHoles(point geom.Vector2) bool {
+	if !pointInPolygon(point, g.polygon) {
+		return false
+	}
+
+	for _, hole := range g.holes {
+		if pointInPolygon(point, hole) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func heuristicEvaluation(a, b geom.Vector2) float32 {
+	x := a.X - b.X
+	y := a.Y - b.Y
+
+	return float32(math.Sqrt(float64(x*x + y*y)))
+}
+
+// pointInPolygon checks if a point p is inside a polygon using the ray casting method.
+func pointInPolygon(p geom.Vector2, poly []geom.Vector2) bool {
+	inside := false
+	n := len(poly)
+	for i := 0; i < n; i++ {
+		p1 := poly[i]
+		p2 := poly[(i+1)%n]
+
+		// Check if the edge (p1->p2) straddles the horizontal line at p.Y
+		condY := (p1.Y <= p.Y && p2.Y > p.Y) || (p2.Y <= p.Y && p1.Y > p.Y)
+		if condY {
+			// Compute the x-coordinate of intersection of the polygon edge with the line y = p.Y
+			xIntersect := p1.X + (p.Y-p1.Y)*(p2.X-p1.X)/(p2.Y-p1.Y)
+			if xIntersect > p.X {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// isLineSegmentInsidePolygon function to check if a line segment is inside a polygon
+func isLineSegmentInsidePolygon(polygon []geom.Vector2, lineStart, lineEnd geom.Vector2) bool {
+	n := len(polygon)
+	for i := 0; i < n; i++ {
+		p1 := polygon[i]
+		p2 := polygon[(i+1)%n]
+		if doLinesIntersect(lineStart, lineEnd, p1, p2) {
+			return true // The line segment intersects the polygon edge
+		}
+	}
+
+	return false
+}
+
+// isPointBlocked checks if point is inside obstacle polygon or closer than agent radius to it
+func isPointBlocked(polygon []geom.Vector2, point geom.Vector2, agentRadius float32) bool {
+	if pointInPolygon(point, polygon) {
+		return true
+	}
+
+	return agentRadius > 0 && pointPolygonDistance(polygon, point) < agentRadius
+}
+
+// isSegmentBlocked checks if segment intersects obstacle polygon or closer than agent radius to it
+func isSegmentBlocked(polygon []geom.Vector2, a, b geom.Vector2, agentRadius float32) bool {
+	if isLineSegmentInsidePolygon(polygon, a, b) {
+		return true
+	}
+
+	if agentRadius <= 0 {
+		return false
+	}
+
+	n := len(polygon)
+	for i := 0; i < n; i++ {
+		p1 := polygon[i]
+		p2 := polygon[(i+1)%n]
+		if geom.Distance(p1, closestPointOnSegment(p1, a, b)) < agentRadius ||
+			geom.Distance(a, closestPointOnSegment(a, p1, p2)) < agentRadius ||
+			geom.Distance(b, closestPointOnSegment(b, p1, p2)) < agentRadius {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pointPolygonDistance return distance from point to polygon outline
+func pointPolygonDistance(polygon []geom.Vector2, point geom.Vector2) float32 {
+	minDist := float32(math.MaxFloat32)
+	n := len(polygon)
+	for i := 0; i < n; i++ {
+		dist := geom.Distance(point, closestPointOnSegment(point, polygon[i], polygon[(i+1)%n]))
+		if dist < minDist {
+			minDist = dist
+		}
+	}
+
+	return minDist
+}
+
+func closestPointOnSegment(p, a, b geom.Vector2) geom.Vector2 {
+	ap := geom.Vector2{X: p.X - a.X, Y: p.Y - a.Y}
+	ab := geom.Vector2{X: b.X - a.X, Y: b.Y - a.Y}
+
+	dotProd := ap.X*ab.X + ap.Y*ab.Y
+	lenSq := ab.X*ab.X + ab.Y*ab.Y
+
+	if lenSq == 0 { // a and b are the same point
+		return a
+	}
+
+	t := dotProd / lenSq
+	if t < 0 {
+		return a
+	} else if t > 1 {
+		return b
+	}
+
+	return geom.Vector2{X: a.X + t*ab.X, Y: a.Y + t*ab.Y}
+}
+
+// Function to check if two lines intersect
+func doLinesIntersect(p1, p2, q1, q2 geom.Vector2) bool {
+	// Convert points to segments
+	// Calculate the orientation
+	o1 := orientation(p1, p2, q1)
+	o2 := orientation(p1, p2, q2)
+	o3 := orientation(q1, q2, p1)
+	o4 := orientation(q1, q2, p2)
+
+	// General case
+	if o1 != o2 && o3 != o4 {
+		return true
+	}
+
+	// Special cases (collinear points)
+	if o1 == 0 && onSegment(p1, q1, p2) {
+		return true
+	}
+	if o2 == 0 && onSegment(p1, q2, p2) {
+		return true
+	}
+	if o3 == 0 && onSegment(q1, p1, q2) {
+		return true
+	}
+	if o4 == 0 && onSegment(q1, p2, q2) {
+		return true
+	}
+	return false
+}
+
+// orientation helper function for intersection calculations
+func orientation(p, q, r geom.Vector2) int {
+	val := (q.Y-r.Y)*(p.X-q.X) - (q.X-r.X)*(p.Y-q.Y)
+	if val == 0 {
+		return 0 // Collinear
+	}
+	if val > 0 {
+		return 1 // Clockwise
+	}
+
+	return 2 // Counterclockwise
+}
+
+// onSegment helper function for segment calculations
+func onSegment(p, q, r geom.Vector2) bool {
+	var (
+		pX, pY = float64(p.X), float64(p.Y)
+		qX, qY = float64(q.X), float64(q.Y)
+		rX, rY = float64(r.X), float64(r.Y)
+	)
+
+	return qX <= math.Max(pX, rX) && qX >= math.Min(pX, rX) && qY <= math.Max(pY, rY) && qY >= math.Min(pY, rY)
+}
+
+// lineSegmentIntersection checks if two line segments intersect, parallel segments don't intersect
+func lineSegmentIntersection(p1, p2, p3, p4 geom.Vector2) bool {
+	s1 := p4.Y - p3.Y
+	s2 := p2.X - p1.X
+	s3 := p4.X - p3.X
+	s4 := p2.Y - p1.Y
+
+	denom := s1*s2 - s3*s4
+	if denom == 0 {
+		return false
+	}
+
+	s5 := p1.Y - p3.Y
+	s6 := p1.X - p3.X
+
+	ua := (s3*s5 - s1*s6) / denom
+	ub := (s2*s5 - s4*s6) / denom
+	return ua >= 0 && ua <= 1 && ub >= 0 && ub <= 1
+}
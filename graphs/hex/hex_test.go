@@ -0,0 +1,139 @@
+package hex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bolom009/geom"
+	"github.com/bolom009/pathfind/mesh"
+	"github.com/stretchr/testify/assert"
+)
+
+var room = []geom.Vector2{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 100}, {X: 0, Y: 100}}
+
+func TestLayout(t *testing.T) {
+	for _, orientation := range []Orientation{Pointy, Flat} {
+		l := layout{orientation: orientation, origin: geom.Vector2{X: 10, Y: -5}, size: 4}
+
+		for q := int32(-5); q <= 5; q++ {
+			for r := int32(-5); r <= 5; r++ {
+				var (
+					a      = Axial{Q: q, R: r}
+					center = l.center(a)
+				)
+
+				assert.Equal(t, a, l.axialOf(center), orientation)
+				for _, corner := range l.corners(center) {
+					assert.InDelta(t, l.size, geom.Distance(center, corner), 1e-3)
+					assert.Equal(t, a, l.axialOf(corner.Lerp(center, 0.1)), orientation)
+				}
+
+				for _, neighbour := range a.Neighbours() {
+					assert.Equal(t, int32(1), a.Distance(neighbour))
+					assert.InDelta(t, l.spacing(), geom.Distance(center, l.center(neighbour)), 1e-3)
+				}
+			}
+		}
+	}
+
+	// pointy hex has corners on vertical axis, flat hex has corners on horizontal axis
+	pointy := layout{orientation: Pointy, size: 4}.corners(geom.Vector2{})
+	assert.InDelta(t, 0, pointy[2].X, 1e-6)
+	assert.InDelta(t, 4, pointy[2].Y, 1e-6)
+	flat := layout{orientation: Flat, size: 4}.corners(geom.Vector2{})
+	assert.InDelta(t, 4, flat[0].X, 1e-6)
+	assert.InDelta(t, 0, flat[0].Y, 1e-6)
+}
+
+func TestGrid_Obstacles(t *testing.T) {
+	var (
+		g        = NewGrid(room, nil, 4)
+		obstacle = mesh.NewObstacle([]geom.Vector2{{X: 40, Y: 40}, {X: 60, Y: 40}, {X: 60, Y: 60}, {X: 40, Y: 60}}, 0, false)
+		center   = geom.Vector2{X: 50, Y: 50}
+	)
+
+	if err := g.Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	visible := len(g.GetVisibility(nil))
+	assert.Equal(t, len(g.Hexes()), visible)
+
+	ids := g.AddObstacles(obstacle)
+	vis := g.GetVisibility(nil)
+	assert.Less(t, len(vis), visible)
+	assert.NotContains(t, vis, g.Center(g.AxialOf(center)))
+	assert.True(t, g.ContainsPoint(center))
+
+	closest, ok := g.GetClosestPoint(center)
+	assert.True(t, ok)
+	assert.Contains(t, vis, closest)
+
+	g.RemoveObstacles(ids...)
+	assert.Len(t, g.GetVisibility(nil), visible)
+
+	// obstacles added before Generate block hexes of generated grid
+	g = NewGrid(room, nil, 4)
+	g.AddObstacles(obstacle)
+	if err := g.Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	assert.NotContains(t, g.GetVisibility(nil), g.Center(g.AxialOf(center)))
+}
+
+func TestGrid_IsRaycastHit(t *testing.T) {
+	var (
+		hole = []geom.Vector2{{X: 40, Y: 40}, {X: 60, Y: 40}, {X: 60, Y: 60}, {X: 40, Y: 60}}
+		g    = NewGrid(room, [][]geom.Vector2{hole}, 4, WithOrientation(Flat))
+	)
+
+	if err := g.Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		start, end geom.Vector2
+		hit        bool
+	}{
+		{name: "free", start: geom.Vector2{X: 10, Y: 10}, end: geom.Vector2{X: 90, Y: 10}},
+		{name: "hole", start: geom.Vector2{X: 10, Y: 50}, end: geom.Vector2{X: 90, Y: 50}, hit: true},
+		{name: "outline", start: geom.Vector2{X: 50, Y: 10}, end: geom.Vector2{X: 50, Y: -10}, hit: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.hit, g.IsRaycastHit(tt.start, tt.end))
+		})
+	}
+}
+
+func TestGrid_PathChanged(t *testing.T) {
+	var (
+		g    = NewGrid(room, nil, 4)
+		near = []geom.Vector2{{X: 10, Y: 10}, {X: 90, Y: 10}}
+		far  = []geom.Vector2{{X: 10, Y: 90}, {X: 90, Y: 90}}
+	)
+
+	if err := g.Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	version := g.Version()
+	assert.False(t, g.PathChanged(near, version))
+
+	// only paths which cross blocked hexes are changed
+	ids := g.AddObstacles(mesh.NewObstacle([]geom.Vector2{{X: 45, Y: 5}, {X: 55, Y: 5}, {X: 55, Y: 15}, {X: 45, Y: 15}}, 0, false))
+	assert.Greater(t, g.Version(), version)
+	assert.True(t, g.PathChanged(near, version))
+	assert.False(t, g.PathChanged(far, version))
+
+	// obstacle which blocks no hexes doesn't change graph
+	version = g.Version()
+	g.AddObstacles(mesh.NewObstacle([]geom.Vector2{{X: 200, Y: 200}, {X: 210, Y: 200}, {X: 210, Y: 210}}, 0, false))
+	assert.Equal(t, version, g.Version())
+
+	// removed obstacle could open shorter path anywhere
+	g.RemoveObstacles(ids...)
+	assert.True(t, g.PathChanged(far, version))
+}
@@ -0,0 +1,139 @@
+package hex
+
+import (
+	"math"
+
+	"github.com/bolom009/geom"
+)
+
+// Orientation is layout of hexes, pointy hexes have corner at top, flat hexes have side at top
+type Orientation uint8
+
+const (
+	Pointy Orientation = iota
+	Flat
+)
+
+// Axial is coordinate of hex, third cube coordinate is -Q-R
+type Axial struct {
+	Q, R int32
+}
+
+// directions of neighbour hexes
+var directions = [6]Axial{{Q: 1, R: 0}, {Q: 1, R: -1}, {Q: 0, R: -1}, {Q: -1, R: 0}, {Q: -1, R: 1}, {Q: 0, R: 1}}
+
+// Add return sum of coordinates
+func (a Axial) Add(b Axial) Axial {
+	return Axial{Q: a.Q + b.Q, R: a.R + b.R}
+}
+
+// Distance return count of moves between hexes
+func (a Axial) Distance(b Axial) int32 {
+	dq, dr := a.Q-b.Q, a.R-b.R
+	return (abs(dq) + abs(dr) + abs(dq+dr)) / 2
+}
+
+// Neighbours return coordinates of six neighbour hexes
+func (a Axial) Neighbours() [6]Axial {
+	var neighbours [6]Axial
+	for i, dir := range directions {
+		neighbours[i] = a.Add(dir)
+	}
+
+	return neighbours
+}
+
+// Hex represent hex with its center and corners
+type Hex struct {
+	Axial
+	Center  geom.Vector2
+	Corners [6]geom.Vector2
+}
+
+// layout converts axial coordinates to positions, hex (0, 0) has center at origin, size is distance from center to corner
+type layout struct {
+	orientation Orientation
+	origin      geom.Vector2
+	size        float32
+}
+
+// center return position of hex center
+func (l layout) center(a Axial) geom.Vector2 {
+	var (
+		q, r   = float64(a.Q), float64(a.R)
+		size   = float64(l.size)
+		sqrt3  = math.Sqrt(3)
+		dx, dy float64
+	)
+
+	if l.orientation == Flat {
+		dx, dy = size*1.5*q, size*(sqrt3/2*q+sqrt3*r)
+	} else {
+		dx, dy = size*(sqrt3*q+sqrt3/2*r), size*1.5*r
+	}
+
+	return geom.Vector2{X: l.origin.X + float32(dx), Y: l.origin.Y + float32(dy)}
+}
+
+// axialOf return coordinate of hex which contains point
+func (l layout) axialOf(point geom.Vector2) Axial {
+	var (
+		x     = float64(point.X-l.origin.X) / float64(l.size)
+		y     = float64(point.Y-l.origin.Y) / float64(l.size)
+		sqrt3 = math.Sqrt(3)
+		q, r  float64
+	)
+
+	if l.orientation == Flat {
+		q, r = 2.0/3*x, -1.0/3*x+sqrt3/3*y
+	} else {
+		q, r = sqrt3/3*x-1.0/3*y, 2.0/3*y
+	}
+
+	return roundAxial(q, r)
+}
+
+// corners return corners of hex by its center, pointy hex starts from corner at 30 degrees, flat one from 0 degrees
+func (l layout) corners(center geom.Vector2) [6]geom.Vector2 {
+	var corners [6]geom.Vector2
+	for i := range corners {
+		angle := math.Pi / 3 * float64(i)
+		if l.orientation == Pointy {
+			angle -= math.Pi / 6
+		}
+
+		corners[i] = geom.Vector2{
+			X: center.X + l.size*float32(math.Cos(angle)),
+			Y: center.Y + l.size*float32(math.Sin(angle)),
+		}
+	}
+
+	return corners
+}
+
+// spacing return distance between centers of neighbour hexes
+func (l layout) spacing() float32 {
+	return l.size * float32(math.Sqrt(3))
+}
+
+// roundAxial return the nearest hex of fractional coordinate by rounding of cube coordinate
+func roundAxial(q, r float64) Axial {
+	var (
+		s          = -q - r
+		rq, rr, rs = math.Round(q), math.Round(r), math.Round(s)
+		dq, dr, ds = math.Abs(rq - q), math.Abs(rr - r), math.Abs(rs - s)
+	)
+
+	switch {
+	case dq > dr && dq > ds:
+		rq = -rr - rs
+	case dr > ds:
+		rr = -rq - rs
+	}
+
+	return Axial{Q: int32(rq), R: int32(rr)}
+}
+
+func abs(v int32) int32 {
+	return max(v, -v)
+}
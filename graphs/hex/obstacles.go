@@ -0,0 +1,178 @@
+package hex
+
+import (
+	"slices"
+
+	"github.com/bolom009/geom"
+	"github.com/bolom009/pathfind/graphs/internal"
+	"github.com/bolom009/pathfind/mesh"
+)
+
+// AddObstacles blocks visible hexes which obstacles overlap (inflated by positive offset) until obstacles are removed
+// Obstacles which are added before Generate block hexes of generated grid. Only links around changed hexes
+// are rebuilt, queries keep reading previous data until update is published
+func (g *Grid) AddObstacles(obstacles ...*mesh.Hole) []uint32 {
+	if len(obstacles) == 0 {
+		return nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var (
+		state   = g.state.Load()
+		blocked = g.cloneBlocked(state)
+		ids     = make([]uint32, len(obstacles))
+		changed []int32
+	)
+
+	for i, obstacle := range obstacles {
+		ids[i] = g.obstaclePool.New(obstacle)
+		if g.index == nil {
+			continue
+		}
+
+		hexes := g.blockedHexes(obstacle)
+		g.obstacleHexes[ids[i]] = hexes
+		changed = blockHexes(blocked, hexes, changed)
+	}
+
+	g.state.Store(g.update(state, blocked, changed))
+	if len(changed) > 0 {
+		g.recordHexes(changed)
+	}
+
+	return ids
+}
+
+// RemoveObstacles unblocks hexes which aren't blocked by other obstacles, unknown ids are ignored
+func (g *Grid) RemoveObstacles(ids ...uint32) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var (
+		state   = g.state.Load()
+		blocked = g.cloneBlocked(state)
+		changed []int32
+	)
+
+	for _, id := range ids {
+		for _, hex := range g.obstacleHexes[id] {
+			if blocked[hex]--; blocked[hex] == 0 {
+				changed = append(changed, hex)
+			}
+		}
+
+		delete(g.obstacleHexes, id)
+		g.obstaclePool.Delete(id)
+	}
+
+	g.state.Store(g.update(state, blocked, changed))
+	if len(changed) > 0 {
+		g.changes.Record(internal.Change[bounds]{Full: true})
+	}
+}
+
+// cloneBlocked return copy of obstacles counts of visible hexes which could be changed by writer
+func (g *Grid) cloneBlocked(state *snapshot) []uint16 {
+	if state.blocked == nil {
+		return make([]uint16, len(g.hexes))
+	}
+
+	return slices.Clone(state.blocked)
+}
+
+// blockHexes increase obstacles counts of hexes and append hexes which become blocked to changed
+func blockHexes(blocked []uint16, hexes, changed []int32) []int32 {
+	for _, hex := range hexes {
+		if blocked[hex]++; blocked[hex] == 1 {
+			changed = append(changed, hex)
+		}
+	}
+
+	return changed
+}
+
+// update return copy of snapshot where changed hexes are blocked or unblocked by obstacles counts
+// Links are rebuilt only for changed hexes and their neighbours, neighbours slices of other hexes are shared
+func (g *Grid) update(state *snapshot, blocked []uint16, changed []int32) *snapshot {
+	if len(changed) == 0 {
+		return &snapshot{visibilityGraph: state.visibilityGraph, blocked: blocked}
+	}
+
+	var (
+		vis   = state.visibilityGraph.Copy()
+		hexes = make(map[int32]struct{}, 7*len(changed))
+	)
+
+	for _, i := range changed {
+		hexes[i] = struct{}{}
+		for _, a := range g.hexes[i].Neighbours() {
+			if j, ok := g.index.hex(a); ok {
+				hexes[j] = struct{}{}
+			}
+		}
+	}
+
+	for i := range hexes {
+		hex := g.hexes[i]
+		if blocked[i] > 0 {
+			vis.DeleteNode(hex.Center)
+			continue
+		}
+
+		vis[hex.Center] = slices.Clip(g.links(hex, blocked))
+	}
+
+	return &snapshot{visibilityGraph: vis, blocked: blocked}
+}
+
+// blockedHexes return indexes of visible hexes which obstacle overlaps, obstacle is inflated by its positive offset
+func (g *Grid) blockedHexes(obstacle *mesh.Hole) []int32 {
+	polygon := obstacle.Points()
+	if len(polygon) == 0 {
+		return nil
+	}
+
+	var (
+		radius = max(obstacle.Offset(), 0)
+		lo, hi = polygon[0], polygon[0]
+	)
+
+	for _, point := range polygon {
+		lo.X, lo.Y = min(lo.X, point.X), min(lo.Y, point.Y)
+		hi.X, hi.Y = max(hi.X, point.X), max(hi.Y, point.Y)
+	}
+
+	hexes := g.hexesIn(geom.Vector2{X: lo.X - radius, Y: lo.Y - radius}, geom.Vector2{X: hi.X + radius, Y: hi.Y + radius})
+	return slices.DeleteFunc(hexes, func(i int32) bool {
+		return !isHexBlocked(polygon, g.hexes[i], radius)
+	})
+}
+
+// isHexBlocked checks if obstacle polygon overlaps hex or is closer than radius to it
+// hex is shrunk a bit, so obstacle which only touches its sides doesn't block it
+func isHexBlocked(polygon []geom.Vector2, hex Hex, radius float32) bool {
+	if pointInPolygon(hex.Center, polygon) {
+		return true
+	}
+
+	var corners [6]geom.Vector2
+	for i, corner := range hex.Corners {
+		corners[i] = corner.Lerp(hex.Center, 1e-3)
+	}
+
+	for _, point := range polygon {
+		if pointInPolygon(point, corners[:]) {
+			return true
+		}
+	}
+
+	for i := range corners {
+		if isSegmentBlocked(polygon, corners[i], corners[(i+1)%len(corners)], radius) {
+			return true
+		}
+	}
+
+	return false
+}
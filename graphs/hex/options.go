@@ -0,0 +1,38 @@
+package hex
+
+import (
+	"github.com/bolom009/astar"
+	"github.com/bolom009/geom"
+	"github.com/bolom009/pathfind/graphs"
+)
+
+type option func(g *Grid)
+
+// WithOrientation set layout of hexes, Pointy is default
+func WithOrientation(orientation Orientation) option {
+	return func(g *Grid) {
+		g.layout.orientation = orientation
+	}
+}
+
+// WithOffset set center of hex (0, 0), by default it's the lowest corner of polygon bounds
+func WithOffset(offset geom.Vector2) option {
+	return func(g *Grid) {
+		g.layout.origin = offset
+	}
+}
+
+// WithCostFunc set cost of segments, it's used as estimate of path cost too
+func WithCostFunc(costFunc astar.CostFunc[geom.Vector2]) option {
+	return func(g *Grid) {
+		g.costFunc = costFunc
+		g.estimateFunc = costFunc
+	}
+}
+
+// WithProgress set callback to report progress of Generate
+func WithProgress(progressFunc graphs.ProgressFunc) option {
+	return func(g *Grid) {
+		g.progressFunc = progressFunc
+	}
+}
@@ -0,0 +1,86 @@
+package hex
+
+import (
+	"math"
+
+	"github.com/bolom009/geom"
+	"github.com/bolom009/pathfind/graphs"
+)
+
+// LineOfSight return check of straight moves over hexes of query, segment is walkable if it goes only through
+// (or along sides of) visible hexes which aren't blocked by extra obstacles and isn't closer than agent radius
+// to obstacles of navOpts.
+// This method makes Grid implement the graphs.SightChecker interface.
+func (g *Grid) LineOfSight(navOpts *graphs.NavOpts) func(a, b geom.Vector2) bool {
+	state := g.state.Load()
+
+	return func(a, b geom.Vector2) bool {
+		if !g.isSegmentWalkable(state, a, b) {
+			return false
+		}
+
+		if navOpts == nil {
+			return true
+		}
+
+		for _, obstacle := range navOpts.Obstacles {
+			if isSegmentBlocked(obstacle.GetPolygon(), a, b, navOpts.AgentRadius) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// isSegmentWalkable checks if segment doesn't overlap invisible or blocked hexes
+// Hexes which segment could overlap are found by points along segment a quarter of size apart,
+// hex of each point and its neighbours cover all hexes which are closer than that to point
+func (g *Grid) isSegmentWalkable(state *snapshot, a, b geom.Vector2) bool {
+	if g.index == nil {
+		return false
+	}
+
+	var (
+		steps   = int(math.Ceil(float64(4*geom.Distance(a, b)/g.layout.size))) + 1
+		checked = make(map[Axial]struct{}, 4*steps)
+	)
+
+	for step := 0; step <= steps; step++ {
+		var (
+			center     = g.layout.axialOf(a.Lerp(b, float32(step)/float32(steps)))
+			neighbours = center.Neighbours()
+		)
+
+		for _, axial := range append(neighbours[:], center) {
+			if _, ok := checked[axial]; ok {
+				continue
+			}
+
+			checked[axial] = struct{}{}
+			if i, ok := g.index.hex(axial); ok && state.blocked[i] == 0 {
+				continue
+			}
+
+			if isHexOverlapped(g.layout, axial, a, b) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// isHexOverlapped checks if segment goes through inner part of hex, segment which only touches its sides doesn't
+func isHexOverlapped(l layout, axial Axial, a, b geom.Vector2) bool {
+	var (
+		center  = l.center(axial)
+		corners = l.corners(center)
+	)
+
+	for i, corner := range corners {
+		corners[i] = corner.Lerp(center, 1e-3)
+	}
+
+	return pointInPolygon(a, corners[:]) || pointInPolygon(b, corners[:]) || isLineSegmentInsidePolygon(corners[:], a, b)
+}
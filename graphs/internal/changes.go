@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"sync/atomic"
+)
+
+// maxChanges is count of last changes which are kept to check paths, older paths are treated as changed
+const maxChanges = 64
+
+// Changes is log of last updates of graph data which is checked by path caches, B is bounds type of graph
+// Log is published as immutable snapshot after graph data, so it's read without locks
+type Changes[B any] struct {
+	log atomic.Pointer[changeLog[B]]
+}
+
+// changeLog is immutable list of last changes of graph data
+type changeLog[B any] struct {
+	version uint64
+	items   []Change[B]
+}
+
+// Change is update of graph data, full change could make any path shorter or longer
+// (obstacle or link is removed, graph is generated), otherwise only paths which cross bounds are affected
+type Change[B any] struct {
+	version uint64
+	Full    bool
+	Bounds  B
+}
+
+// Version return count of changes of graph data
+func (c *Changes[B]) Version() uint64 {
+	return c.load().version
+}
+
+// PathChanged checks if path which was found at version could be changed by later updates
+// Path is changed by full changes and by changes which bounds it crosses, paths older than kept changes are changed
+func (c *Changes[B]) PathChanged(version uint64, crossed func(bounds B) bool) bool {
+	log := c.load()
+	if version == log.version {
+		return false
+	}
+
+	if len(log.items) == 0 || log.items[0].version > version+1 {
+		return true
+	}
+
+	for _, change := range log.items {
+		if change.version <= version {
+			continue
+		}
+
+		if change.Full || crossed(change.Bounds) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Record publish next version of graph data, writer must hold lock of graph
+func (c *Changes[B]) Record(change Change[B]) {
+	var (
+		prev  = c.load()
+		items = make([]Change[B], 0, min(len(prev.items)+1, maxChanges))
+	)
+
+	if len(prev.items) >= maxChanges {
+		items = append(items, prev.items[len(prev.items)-maxChanges+1:]...)
+	} else {
+		items = append(items, prev.items...)
+	}
+
+	change.version = prev.version + 1
+	c.log.Store(&changeLog[B]{version: change.version, items: append(items, change)})
+}
+
+func (c *Changes[B]) load() *changeLog[B] {
+	if log := c.log.Load(); log != nil {
+		return log
+	}
+
+	return &changeLog[B]{}
+}
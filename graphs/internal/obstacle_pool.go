@@ -1,15 +1,13 @@
-package recast
+// Package internal contains data of dynamic graphs which is shared by graph types:
+// pool of extra obstacles and log of changes which is checked by path caches
+package internal
 
 import (
 	"github.com/bolom009/pathfind/mesh"
 )
 
-type obstaclePolyPair struct {
-	oId uint32
-	pId int
-}
-
-type obstaclePool struct {
+// ObstaclePool keeps extra obstacles of graph by id, ids of deleted obstacles are reused
+type ObstaclePool struct {
 	byID   map[uint32]uint32
 	items  []*mesh.Hole
 	ids    []uint32
@@ -17,8 +15,8 @@ type obstaclePool struct {
 	nextID uint32
 }
 
-func newObstaclePool(cap int) *obstaclePool {
-	return &obstaclePool{
+func NewObstaclePool(cap int) *ObstaclePool {
+	return &ObstaclePool{
 		byID:   make(map[uint32]uint32, cap),
 		items:  make([]*mesh.Hole, 0, cap),
 		ids:    make([]uint32, 0, cap),
@@ -27,7 +25,7 @@ func newObstaclePool(cap int) *obstaclePool {
 	}
 }
 
-func (p *obstaclePool) New(h *mesh.Hole) uint32 {
+func (p *ObstaclePool) New(h *mesh.Hole) uint32 {
 	var id uint32
 	if n := len(p.free); n > 0 {
 		id = p.free[n-1]
@@ -46,7 +44,7 @@ func (p *obstaclePool) New(h *mesh.Hole) uint32 {
 	return id
 }
 
-func (p *obstaclePool) Delete(id uint32) {
+func (p *ObstaclePool) Delete(id uint32) {
 	idx, ok := p.byID[id]
 	if !ok {
 		return
@@ -66,6 +64,11 @@ func (p *obstaclePool) Delete(id uint32) {
 	p.free = append(p.free, id)
 }
 
-func (p *obstaclePool) GetList() []*mesh.Hole {
+func (p *ObstaclePool) GetList() []*mesh.Hole {
 	return p.items
 }
+
+// IDs return ids of obstacles in order of GetList
+func (p *ObstaclePool) IDs() []uint32 {
+	return p.ids
+}
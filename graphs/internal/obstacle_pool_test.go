@@ -1,4 +1,4 @@
-package recast
+package internal
 
 import (
 	"testing"
@@ -13,7 +13,7 @@ func TestObstaclePool(t *testing.T) {
 	obstacle2 := []geom.Vector2{{10, 10}, {10, 30}, {30, 10}}
 	deleteList := make([]uint32, 0)
 
-	pool := newObstaclePool(30)
+	pool := NewObstaclePool(30)
 	idx := pool.New(mesh.NewObstacle(obstacle, 3, true))
 	deleteList = append(deleteList, idx)
 	assert.Equal(t, uint32(1), idx)
@@ -0,0 +1,53 @@
+package recast
+
+import (
+	"slices"
+
+	"github.com/bolom009/geom"
+	"github.com/bolom009/pathfind/graphs/internal"
+	"github.com/bolom009/pathfind/mesh"
+)
+
+// Version return count of changes of recast data (Generate, obstacles and off-mesh links updates)
+// This method makes Recast implement the graphs.ChangeTracker interface.
+func (r *Recast) Version() uint64 {
+	return r.changes.Version()
+}
+
+// PathChanged checks if path which was found at version could be changed by later updates
+// Path is changed by added obstacles which bounds (inflated by agent radius) it crosses
+// and by any removed obstacle, because new shorter path could go anywhere.
+// This method makes Recast implement the graphs.ChangeTracker interface.
+func (r *Recast) PathChanged(path []geom.Vector2, version uint64) bool {
+	return r.changes.PathChanged(version, func(bounds BoundingBox) bool {
+		return pathCrossesBounds(path, bounds)
+	})
+}
+
+// recordObstacles publish change of added obstacles bounds inflated by their offsets and the widest agent radius
+// paths which don't cross bounds stay the shortest, because obstacle only removes part of walkable area
+func (r *Recast) recordObstacles(obstacles []*mesh.Hole) {
+	bounds := getBoundingBox(obstacles[0].Points())
+	for _, obstacle := range obstacles {
+		box := getBoundingBox(obstacle.Points())
+		inflate := slices.Max(layerRadii(r.agentRadii)) + max(obstacle.Offset(), 0)
+		bounds.MinX, bounds.MinY = min(bounds.MinX, box.MinX-inflate), min(bounds.MinY, box.MinY-inflate)
+		bounds.MaxX, bounds.MaxY = max(bounds.MaxX, box.MaxX+inflate), max(bounds.MaxY, box.MaxY+inflate)
+	}
+
+	r.changes.Record(internal.Change[BoundingBox]{Bounds: bounds})
+}
+
+func pathCrossesBounds(path []geom.Vector2, bounds BoundingBox) bool {
+	if len(path) == 1 {
+		return pointInBoundingBox(path[0], bounds)
+	}
+
+	for i := 0; i < len(path)-1; i++ {
+		if lineIntersectsBoundingBox(path[i], path[i+1], bounds) {
+			return true
+		}
+	}
+
+	return false
+}
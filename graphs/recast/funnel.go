@@ -0,0 +1,110 @@
+package recast
+
+import (
+	"slices"
+
+	"github.com/bolom009/geom"
+)
+
+// portalPath pulls path through corridors found over portals from start to dest and back, the shorter path is returned
+// Entry points of corridor search are greedy, so search in both directions rarely misses the shortest corridor
+func (m *navMesh) portalPath(start, dest geom.Vector2, startTri, destTri int32) []geom.Vector2 {
+	corridor := m.corridor(start, dest, startTri, destTri)
+	if len(corridor) == 0 {
+		return nil
+	}
+
+	path := m.funnel(start, dest, corridor)
+	if back := m.corridor(dest, start, destTri, startTri); len(back) > 0 {
+		slices.Reverse(back)
+		if backPath := m.funnel(start, dest, back); backPath != nil && (path == nil || pathLength(backPath) < pathLength(path)) {
+			path = backPath
+		}
+	}
+
+	return path
+}
+
+// funnel pulls path from start to dest through triangle corridor (simple stupid funnel algorithm)
+// corridor must start with triangle of start point and end with triangle of dest point
+func (m *navMesh) funnel(start, dest geom.Vector2, corridor []int32) []geom.Vector2 {
+	lefts := make([]geom.Vector2, 0, len(corridor)+1)
+	rights := make([]geom.Vector2, 0, len(corridor)+1)
+	lefts = append(lefts, start)
+	rights = append(rights, start)
+	for i := 0; i < len(corridor)-1; i++ {
+		left, right, ok := m.portal(corridor[i], corridor[i+1])
+		if !ok {
+			return nil
+		}
+
+		lefts = append(lefts, left)
+		rights = append(rights, right)
+	}
+	lefts = append(lefts, dest)
+	rights = append(rights, dest)
+
+	var (
+		path                       = []geom.Vector2{start}
+		apex, left, right          = start, start, start
+		apexIdx, leftIdx, rightIdx = 0, 0, 0
+		portalsNum                 = len(lefts)
+	)
+
+	for i := 1; i < portalsNum; i++ {
+		pl, pr := lefts[i], rights[i]
+
+		// update right vertex
+		if triArea2(apex, right, pr) >= 0 {
+			if apex == right || triArea2(apex, left, pr) < 0 {
+				// tighten the funnel
+				right = pr
+				rightIdx = i
+			} else {
+				// right over left, insert left to path and restart scan from it
+				path = appendPoint(path, left)
+				apex = left
+				apexIdx = leftIdx
+				right = apex
+				rightIdx = apexIdx
+				i = apexIdx
+				continue
+			}
+		}
+
+		// update left vertex
+		if triArea2(apex, left, pl) <= 0 {
+			if apex == left || triArea2(apex, right, pl) > 0 {
+				// tighten the funnel
+				left = pl
+				leftIdx = i
+			} else {
+				// left over right, insert right to path and restart scan from it
+				path = appendPoint(path, right)
+				apex = right
+				apexIdx = rightIdx
+				left = apex
+				leftIdx = apexIdx
+				i = apexIdx
+				continue
+			}
+		}
+	}
+
+	return appendPoint(path, dest)
+}
+
+// appendPoint add point to path if it's not equal to the last one
+func appendPoint(path []geom.Vector2, point geom.Vector2) []geom.Vector2 {
+	if len(path) > 0 && path[len(path)-1] == point {
+		return path
+	}
+
+	return append(path, point)
+}
+
+// triArea2 return doubled signed area of triangle a, b, c
+// positive value means c is on the left side of a->b
+func triArea2(a, b, c geom.Vector2) float32 {
+	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+}
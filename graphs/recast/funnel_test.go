@@ -0,0 +1,145 @@
+package recast
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/bolom009/astar"
+	"github.com/bolom009/geom"
+	"github.com/bolom009/pathfind/mesh"
+	"github.com/stretchr/testify/assert"
+)
+
+// uShape is polygon with two arms connected by bottom corridor
+var uShape = []geom.Vector2{
+	{X: 0, Y: 0}, {X: 30, Y: 0}, {X: 30, Y: 30}, {X: 20, Y: 30},
+	{X: 20, Y: 10}, {X: 10, Y: 10}, {X: 10, Y: 30}, {X: 0, Y: 30},
+}
+
+func TestRecast_StraightPath(t *testing.T) {
+	recastGraph := NewRecast([]*mesh.Polygon{mesh.NewPolygon(uShape, nil, nil, 0)})
+	if err := recastGraph.Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		start geom.Vector2
+		dest  geom.Vector2
+		want  []geom.Vector2
+	}{
+		{
+			name:  "same triangle",
+			start: geom.Vector2{X: 1, Y: 1},
+			dest:  geom.Vector2{X: 2, Y: 1},
+			want:  []geom.Vector2{{X: 1, Y: 1}, {X: 2, Y: 1}},
+		},
+		{
+			name:  "visible through corridor",
+			start: geom.Vector2{X: 2, Y: 5},
+			dest:  geom.Vector2{X: 28, Y: 5},
+			want:  []geom.Vector2{{X: 2, Y: 5}, {X: 28, Y: 5}},
+		},
+		{
+			name:  "around inner corners",
+			start: geom.Vector2{X: 5, Y: 25},
+			dest:  geom.Vector2{X: 25, Y: 25},
+			want: []geom.Vector2{
+				{X: 5, Y: 25}, {X: 10, Y: 10}, {X: 20, Y: 10}, {X: 25, Y: 25},
+			},
+		},
+		{
+			name:  "outside area",
+			start: geom.Vector2{X: 5, Y: 25},
+			dest:  geom.Vector2{X: 15, Y: 25},
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, recastGraph.StraightPath(tt.start, tt.dest))
+		})
+	}
+}
+
+func TestRecast_StraightPathOutOfArea(t *testing.T) {
+	recastGraph := NewRecast([]*mesh.Polygon{mesh.NewPolygon(uShape, nil, nil, 0)}, WithSearchOutOfArea(true))
+	if err := recastGraph.Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		start = geom.Vector2{X: -5, Y: 5}
+		dest  = geom.Vector2{X: 28, Y: 5}
+	)
+
+	path := recastGraph.StraightPath(start, dest)
+	assert.Equal(t, []geom.Vector2{start, {X: 0, Y: 5}, dest}, path)
+}
+
+func TestRecast_StraightPathNotLonger(t *testing.T) {
+	rPolygons, err := loadLargeLocation()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recastGraph := NewRecast(rPolygons)
+	if err = recastGraph.Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	for range 500 {
+		start, dest := randomMeshPoint(recastGraph, rnd), randomMeshPoint(recastGraph, rnd)
+		vis := recastGraph.AggregationGraph(start, dest, nil)
+		path := astar.FindPath[geom.Vector2](vis, start, dest, recastGraph.HashIndex, recastGraph.Cost, recastGraph.Estimate)
+		if path == nil {
+			continue
+		}
+
+		straight := recastGraph.StraightPath(start, dest)
+		assert.Equal(t, start, straight[0])
+		assert.Equal(t, dest, straight[len(straight)-1])
+		assert.LessOrEqual(t, pathLength(straight), pathLength(path)+1e-3, [2]geom.Vector2{start, dest})
+	}
+}
+
+// randomMeshPoint return random point of triangles of recast
+func randomMeshPoint(r *Recast, rnd *rand.Rand) geom.Vector2 {
+	var (
+		triangles = r.Triangles()
+		triangle  = triangles[rnd.Intn(len(triangles))]
+		u, v      = rnd.Float32(), rnd.Float32()
+	)
+
+	if u+v > 1 {
+		u, v = 1-u, 1-v
+	}
+
+	return triangle[0].Add(triangle[1].Sub(triangle[0]).Mul(u)).Add(triangle[2].Sub(triangle[0]).Mul(v))
+}
+
+// BenchmarkRecast_StraightPath-16    	   60079	     18393 ns/op	   11640 B/op	      22 allocs/op
+func BenchmarkRecast_StraightPath(b *testing.B) {
+	rPolygons, err := loadLargeLocation()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	var (
+		start       = geom.Vector2{X: 202, Y: -268}
+		dest        = geom.Vector2{X: -4, Y: 84}
+		recastGraph = NewRecast(rPolygons, WithSearchOutOfArea(true))
+	)
+
+	_ = recastGraph.Generate(context.Background())
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for b.Loop() {
+		_ = recastGraph.StraightPath(start, dest)
+	}
+}
@@ -0,0 +1,590 @@
+package recast
+
+import (
+	"context"
+	"math"
+	"slices"
+
+	"github.com/bolom009/delaunay"
+	"github.com/bolom009/geom"
+	goclipper2 "github.com/bolom009/go-clipper2"
+	"github.com/bolom009/pathfind/graphs"
+	"github.com/bolom009/pathfind/mesh"
+)
+
+// layer represent navigation data generated for one agent radius
+// agent radius is added to offsets of polygons, holes and extra obstacles
+type layer struct {
+	agentRadius     float32
+	regions         []*region
+	triangles       []Triangle
+	triAreas        []mesh.AreaType
+	navMesh         *navMesh
+	visibilityGraph graphs.Graph[geom.Vector2]
+	edgeRefs        map[triEdge]int32
+	vertices        []geom.Vector2
+	// seams link vertices of neighbour regions
+	seams []seam
+
+	// extra obstacles, clippedAreas are area types of clipped polygons
+	extraClippedPolygons []*mesh.Polygon
+	clippedAreas         []mesh.AreaType
+	extraEdges           []*edge
+
+	// off-mesh links and triangles of their points (-1 if point is out of layer)
+	links    *offMeshLinks
+	linkTris [][2]int32
+}
+
+// region is part of clipped polygon bounded by tile (whole clipped polygon if tiles are disabled)
+// extra obstacles are cut and triangulated per region, so rebuild touches only affected regions
+type region struct {
+	polygon   *mesh.Polygon
+	bounds    BoundingBox
+	obstacles map[uint32]goclipper2.PathsD
+	polygons  []*mesh.Polygon
+	triangles []Triangle
+	navMesh   *navMesh
+	area      mesh.AreaType
+	dirty     bool
+}
+
+func newLayer(agentRadius float32) *layer {
+	return &layer{
+		agentRadius:     agentRadius,
+		triangles:       make([]Triangle, 0),
+		visibilityGraph: make(graphs.Graph[geom.Vector2]),
+		edgeRefs:        make(map[triEdge]int32),
+	}
+}
+
+// layer return generated layer with the smallest agent radius which fits navOpts agent radius
+// if agent radius is bigger than all layers empty layer is returned, so agent doesn't fit anywhere.
+// Graph without agent radii has only base layer, agent radius inflates obstacles of query then
+func (r *Recast) layer(navOpts *graphs.NavOpts) *layer {
+	layers := r.loadLayers()
+	if navOpts == nil || navOpts.AgentRadius <= 0 || len(layers) == 1 {
+		return layers[0]
+	}
+
+	for _, l := range layers {
+		if l.agentRadius >= navOpts.AgentRadius {
+			return l
+		}
+	}
+
+	return newLayer(navOpts.AgentRadius)
+}
+
+// clone return copy of layer which is rebuilt while queries read origin one
+// rebuild replaces reader data instead of changing it, so only visibility graph is copied.
+// Regions and edge references are used by updates only and shared with origin
+func (l *layer) clone() *layer {
+	c := *l
+	c.visibilityGraph = l.visibilityGraph.Copy()
+	return &c
+}
+
+// isDirty checks if any region of layer is changed by extra obstacles
+func (l *layer) isDirty() bool {
+	for _, reg := range l.regions {
+		if reg.dirty {
+			return true
+		}
+	}
+
+	return false
+}
+
+// layerRadii return sorted unique list of agent radii for layers, base layer with zero radius is always first
+func layerRadii(agentRadii []float32) []float32 {
+	radii := []float32{0}
+	for _, agentRadius := range agentRadii {
+		if agentRadius > 0 {
+			radii = append(radii, agentRadius)
+		}
+	}
+
+	slices.Sort(radii)
+	return slices.Compact(radii)
+}
+
+// generateLayer offset and triangulate polygons for layer agent radius
+// progress of layer is reported in range from..from+step, first half is offsets and second one is triangulation
+func (r *Recast) generateLayer(ctx context.Context, l *layer, from, step float32) error {
+	oPolygons := make([]*mesh.Polygon, 0, len(r.polygons))
+	// offset each polygon and their innerHole + obstacles
+	// then union results from offsets for to get new sub polygons
+	for i, polygon := range r.polygons {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		polyOffsets := r.getPolyOffsetsWithUnion(polygon, l.agentRadius)
+		oPolygons = append(oPolygons, polygonsFromPaths(polyOffsets)...)
+		r.reportProgress(from + step*0.5*float32(i+1)/float32(len(r.polygons)))
+	}
+
+	if r.tileSize > 0 {
+		oPolygons = splitByTiles(oPolygons, r.tileSize)
+	}
+
+	// carve cost areas, so each region lies in one area
+	oPolygons, areaTypes := splitByAreas(oPolygons, r.areas.Areas())
+
+	// process recast data based on all polygons what we got during clipper2 process
+	l.regions = make([]*region, len(oPolygons))
+	for i, polygon := range oPolygons {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		polygons, triangles := triangulate([]*mesh.Polygon{polygon})
+		l.regions[i] = &region{
+			polygon:   polygons[0],
+			bounds:    getBoundingBox(polygon.Points()),
+			obstacles: make(map[uint32]goclipper2.PathsD),
+			polygons:  polygons,
+			triangles: triangles,
+			navMesh:   buildNavMesh(triangles),
+			area:      areaTypes[i],
+		}
+
+		r.reportProgress(from + step*(0.5+0.5*float32(i+1)/float32(len(oPolygons))))
+	}
+
+	l.prepareLayer()
+	return nil
+}
+
+// prepareLayer link triangles of generated or loaded regions to visibility graph and join their adjacency
+func (l *layer) prepareLayer() {
+	l.visibilityGraph = make(graphs.Graph[geom.Vector2])
+	l.edgeRefs = make(map[triEdge]int32)
+	l.seams = nil
+	for _, reg := range l.regions {
+		l.linkTriangles(reg.triangles)
+	}
+
+	l.prepareRegions()
+	l.prepareVertices()
+	l.visibilityGraph.Clip()
+}
+
+// rebuildLayer cut and triangulate only regions changed by extra obstacles
+// then relink their triangles in visibility graph
+func (r *Recast) rebuildLayer(l *layer) {
+	changed := false
+	for _, reg := range l.regions {
+		if !reg.dirty {
+			continue
+		}
+
+		// TODO do we need to recalc raycast? raycast - check visibility to enemy through obstacle
+		polygons, triangles := triangulate(reg.cut())
+
+		l.unlinkTriangles(reg.triangles)
+		reg.polygons, reg.triangles, reg.navMesh = polygons, triangles, buildNavMesh(triangles)
+		l.linkTriangles(reg.triangles)
+
+		reg.dirty = false
+		changed = true
+	}
+
+	if !changed {
+		return
+	}
+
+	l.prepareRegions()
+	l.prepareVertices()
+	l.visibilityGraph.Clip()
+}
+
+// cut return region polygon with holes made by extra obstacles
+func (reg *region) cut() []*mesh.Polygon {
+	if len(reg.obstacles) == 0 {
+		return []*mesh.Polygon{reg.polygon}
+	}
+
+	subject := goclipper2.PathsD{toPathD(reg.polygon.Points())}
+	for _, hole := range reg.polygon.Holes() {
+		subject = append(subject, toPathD(hole.Points()))
+	}
+
+	for _, extraObstacle := range reg.obstacles {
+		subject = append(subject, extraObstacle...)
+	}
+
+	// union clipped polygons with external subject
+	return polygonsFromPaths(unionPathsD(subject, goclipper2.Positive))
+}
+
+// addObstacle link extra obstacle inflated by its offset and agent radius to regions which it overlaps
+func (l *layer) addObstacle(id uint32, obstacle *mesh.Hole) {
+	rPoints := goclipper2.ReversePath(obstacle.Points())
+	newPaths := inflatePathsD(goclipper2.PathsD{toPathD(rPoints)}, float64(obstacle.Offset()+l.agentRadius), goclipper2.Miter, goclipper2.Polygon)
+	if len(newPaths) == 0 {
+		return
+	}
+
+	bounds := getBoundingBox(toPoint2(newPaths[0]))
+	for _, reg := range l.regions {
+		if boundingBoxesOverlap(reg.bounds, bounds) {
+			reg.obstacles[id] = newPaths
+			reg.dirty = true
+		}
+	}
+}
+
+func (l *layer) removeObstacle(id uint32) {
+	for _, reg := range l.regions {
+		if _, ok := reg.obstacles[id]; ok {
+			delete(reg.obstacles, id)
+			reg.dirty = true
+		}
+	}
+}
+
+// polygonsFromPaths split clipper paths to polygons with holes
+// positive paths are polygons, negative paths are holes of polygons which contain them
+func polygonsFromPaths(paths goclipper2.PathsD) []*mesh.Polygon {
+	rHoles := make([]*mesh.Hole, 0)
+	subPolygons := make([][]geom.Vector2, 0)
+	for _, path := range paths {
+		cPoly := toPoint2(path)
+		if !goclipper2.IsPositiveD(path) {
+			rHoles = append(rHoles, mesh.NewObstacle(cPoly, 0, false))
+		} else {
+			subPolygons = append(subPolygons, cPoly)
+		}
+	}
+
+	polygons := make([]*mesh.Polygon, 0, len(subPolygons))
+	for _, subPolygon := range subPolygons {
+		subPolygonHoles := make([]*mesh.Hole, 0, len(rHoles))
+		for _, hole := range rHoles {
+			if isPolygonAFullyInsideB(hole.Points(), subPolygon, true) {
+				subPolygonHoles = append(subPolygonHoles, hole)
+			}
+		}
+
+		polygons = append(polygons, mesh.NewPolygon(subPolygon, subPolygonHoles, nil, 0))
+	}
+
+	return polygons
+}
+
+// splitByTiles cut polygons by square tiles aligned to world origin
+func splitByTiles(polygons []*mesh.Polygon, tileSize float32) []*mesh.Polygon {
+	tiles := make([]*mesh.Polygon, 0, len(polygons))
+	for _, polygon := range polygons {
+		subject := goclipper2.PathsD{toPathD(polygon.Points())}
+		for _, hole := range polygon.Holes() {
+			subject = append(subject, toPathD(hole.Points()))
+		}
+
+		bounds := getBoundingBox(polygon.Points())
+		minX := float32(math.Floor(float64(bounds.MinX/tileSize))) * tileSize
+		minY := float32(math.Floor(float64(bounds.MinY/tileSize))) * tileSize
+		for x := minX; x < bounds.MaxX; x += tileSize {
+			for y := minY; y < bounds.MaxY; y += tileSize {
+				tile := toPathD([]geom.Vector2{
+					{X: x, Y: y}, {X: x + tileSize, Y: y}, {X: x + tileSize, Y: y + tileSize}, {X: x, Y: y + tileSize},
+				})
+				if !goclipper2.IsPositiveD(tile) {
+					tile = goclipper2.ReversePath(tile)
+				}
+
+				tiles = append(tiles, polygonsFromPaths(intersectPathsD(subject, goclipper2.PathsD{tile}, goclipper2.Positive))...)
+			}
+		}
+	}
+
+	return tiles
+}
+
+// splitByAreas cut polygons by outlines of cost areas and return area type of each part
+// later areas overlap earlier ones, parts out of areas have mesh.AreaDefault
+func splitByAreas(polygons []*mesh.Polygon, areas []*mesh.Hole) ([]*mesh.Polygon, []mesh.AreaType) {
+	if len(areas) == 0 {
+		return polygons, make([]mesh.AreaType, len(polygons))
+	}
+
+	var (
+		parts     = make([]*mesh.Polygon, 0, len(polygons))
+		areaTypes = make([]mesh.AreaType, 0, len(polygons))
+	)
+
+	for _, polygon := range polygons {
+		subject := goclipper2.PathsD{toPathD(polygon.Points())}
+		for _, hole := range polygon.Holes() {
+			subject = append(subject, toPathD(hole.Points()))
+		}
+
+		for i := len(areas) - 1; i >= 0 && len(subject) > 0; i-- {
+			clip := toPathD(areas[i].Points())
+			if !goclipper2.IsPositiveD(clip) {
+				clip = goclipper2.ReversePath(clip)
+			}
+
+			for _, part := range polygonsFromPaths(intersectPathsD(subject, goclipper2.PathsD{clip}, goclipper2.Positive)) {
+				parts = append(parts, part)
+				areaTypes = append(areaTypes, areas[i].Area())
+			}
+
+			subject = differencePathsD(subject, goclipper2.PathsD{clip}, goclipper2.Positive)
+		}
+
+		for _, part := range polygonsFromPaths(subject) {
+			parts = append(parts, part)
+			areaTypes = append(areaTypes, mesh.AreaDefault)
+		}
+	}
+
+	return parts, areaTypes
+}
+
+// triangulate split polygons with holes to triangles
+// returned polygons are copies of source polygons without offsets
+func triangulate(polygons []*mesh.Polygon) ([]*mesh.Polygon, []Triangle) {
+	triLen := 0
+	for _, polygon := range polygons {
+		triLen += len(polygon.Points())
+		for _, hole := range polygon.Holes() {
+			triLen += len(hole.Points())
+		}
+	}
+
+	var (
+		cPolygons = make([]*mesh.Polygon, 0, len(polygons))
+		triangles = make([]Triangle, 0, triLen)
+	)
+
+	for _, polygon := range polygons {
+		innerHoles := make([]*mesh.Hole, len(polygon.InnerHoles()))
+		for i, innerHole := range polygon.InnerHoles() {
+			innerHoles[i] = mesh.NewObstacle(innerHole.Points(), 0, innerHole.Viewable())
+		}
+
+		obstacles := make([]*mesh.Hole, len(polygon.Obstacles()))
+		for i, obstacle := range polygon.Obstacles() {
+			obstacles[i] = mesh.NewObstacle(obstacle.Points(), 0, obstacle.Viewable())
+		}
+
+		poly := mesh.NewPolygon(polygon.Points(), innerHoles, obstacles, 0)
+		cPolygons = append(cPolygons, poly)
+
+		pp := make([]*delaunay.Point, 0)
+		for _, p := range poly.Points() {
+			pp = append(pp, delaunay.NewPoint(p.X, p.Y))
+		}
+
+		hh := make([][]*delaunay.Point, 0)
+		for _, hole := range poly.Holes() {
+			newHole := make([]*delaunay.Point, len(hole.Points()))
+			for i, p := range hole.Points() {
+				newHole[i] = delaunay.NewPoint(p.X, p.Y)
+			}
+
+			hh = append(hh, newHole)
+		}
+
+		d := delaunay.NewSweepContext(pp, hh)
+
+		cTriangles := convertTriangles(d.Triangulate())
+		triangles = append(triangles, cTriangles...)
+	}
+
+	return cPolygons, triangles
+}
+
+// prepareRegions join triangle adjacency and collect polygons of all regions
+func (l *layer) prepareRegions() {
+	parts := make([]*navMesh, len(l.regions))
+	l.extraClippedPolygons = make([]*mesh.Polygon, 0, len(l.regions))
+	l.clippedAreas = make([]mesh.AreaType, 0, len(l.regions))
+	for i, reg := range l.regions {
+		parts[i] = reg.navMesh
+		l.extraClippedPolygons = append(l.extraClippedPolygons, reg.polygons...)
+		l.clippedAreas = append(l.clippedAreas, slices.Repeat([]mesh.AreaType{reg.area}, len(reg.polygons))...)
+	}
+
+	navMesh, seams := joinNavMeshes(parts)
+	for _, seam := range l.seams {
+		l.unlinkSegment(seam.a, seam.b)
+	}
+	for _, seam := range seams {
+		l.linkSegment(seam.a, seam.b)
+	}
+
+	l.navMesh, l.seams = navMesh, seams
+	l.triangles = l.navMesh.triangles
+	l.triAreas = make([]mesh.AreaType, 0, len(l.triangles))
+	for _, reg := range l.regions {
+		l.triAreas = append(l.triAreas, slices.Repeat([]mesh.AreaType{reg.area}, len(reg.triangles))...)
+	}
+	l.prepareEdges(len(l.extraEdges))
+}
+
+func (l *layer) prepareVertices() {
+	l.vertices = make([]geom.Vector2, len(l.visibilityGraph))
+
+	i := 0
+	for v := range l.visibilityGraph {
+		l.vertices[i] = v
+		i++
+	}
+}
+
+// linkTriangles link triangle vertices in visibility graph
+func (l *layer) linkTriangles(triangles []Triangle) {
+	for _, triangle := range triangles {
+		for j := 0; j < 3; j++ {
+			l.linkSegment(triangle[j], triangle[(j+1)%3])
+		}
+	}
+}
+
+// unlinkTriangles delete triangle edges from visibility graph which are not used by other triangles
+func (l *layer) unlinkTriangles(triangles []Triangle) {
+	for _, triangle := range triangles {
+		for j := 0; j < 3; j++ {
+			l.unlinkSegment(triangle[j], triangle[(j+1)%3])
+		}
+	}
+}
+
+// linkSegment link points in visibility graph
+// segments shared by several triangles or seams are counted to be linked once
+func (l *layer) linkSegment(a, b geom.Vector2) {
+	key := newTriEdge(a, b)
+
+	l.edgeRefs[key]++
+	if l.edgeRefs[key] == 1 {
+		l.visibilityGraph.LinkBoth(a, b, 2)
+	}
+}
+
+// unlinkSegment delete link of points from visibility graph when it isn't used anymore
+func (l *layer) unlinkSegment(a, b geom.Vector2) {
+	key := newTriEdge(a, b)
+
+	l.edgeRefs[key]--
+	if l.edgeRefs[key] > 0 {
+		return
+	}
+
+	delete(l.edgeRefs, key)
+	l.visibilityGraph.DeleteNeighbour(a, b)
+	l.visibilityGraph.DeleteNeighbour(b, a)
+	if len(l.visibilityGraph[a]) == 0 {
+		l.visibilityGraph.DeleteNode(a)
+	}
+	if len(l.visibilityGraph[b]) == 0 {
+		l.visibilityGraph.DeleteNode(b)
+	}
+}
+
+func (l *layer) prepareEdges(edgeLen int) {
+	l.extraEdges = make([]*edge, 0, edgeLen)
+	for _, polygon := range l.extraClippedPolygons {
+		l.extraEdges = append(l.extraEdges, polyToEdges(polygon.Points())...)
+		for _, hole := range polygon.Holes() {
+			l.extraEdges = append(l.extraEdges, polyToEdges(hole.Points())...)
+		}
+	}
+}
+
+func (l *layer) getVisiblePoints(point geom.Vector2) []geom.Vector2 {
+	visiblePoints := make([]geom.Vector2, len(l.extraEdges))
+	count := 0
+
+	// TODO very fast check ~11146ns
+	for _, edge := range l.extraEdges {
+		if pointOnSegment(point, edge.a, edge.b, 1e-1) {
+			visiblePoints[count] = edge.a
+			count++
+			visiblePoints[count] = edge.b
+			count++
+		}
+	}
+
+	// TODO better but not so accurate :( ~99853ns
+	//for _, v := range r.vertices {
+	//	hasIntersection := false
+	//	for _, edge := range r.edges {
+	//		if lineSegmentIntersection(edge.a, edge.b, v, point) {
+	//			hasIntersection = true
+	//			break
+	//		}
+	//	}
+	//
+	//	if !hasIntersection {
+	//		visiblePoints[count] = v
+	//		count++
+	//	}
+	//}
+
+	// TODO more accurate but expensive :( ~190585ns
+	//for _, polygon := range r.polygons {
+	//	points := polygon.Points()
+	//	if !pointInPolygon(point, points) {
+	//		continue
+	//	}
+	//
+	//	holes := polygon.Holes()
+	//	for _, v := range r.vertices {
+	//		if isLineSegmentInsidePolygonOrHoles(points, holes, point, v) {
+	//			visiblePoints[count] = v
+	//			count++
+	//		}
+	//	}
+	//}
+
+	return visiblePoints[:count]
+}
+
+// closestPointOnPolygon finds the closest point on the polygon's boundary to the given point
+func (l *layer) closestPointOnPolygon(startPoint geom.Vector2) (geom.Vector2, bool) {
+	minDist := float32(math.MaxFloat32)
+	closestFoundPoint := geom.Vector2{}
+	exist := false
+
+	// Check exterior ring
+	for _, polygon := range l.extraClippedPolygons {
+		clippedPolygon := polygon.Points()
+		for i := 0; i < len(clippedPolygon); i++ {
+			p1 := clippedPolygon[i]
+			p2 := clippedPolygon[(i+1)%len(clippedPolygon)] // Wrap around for last segment
+
+			closestOnSeg := closestPointOnSegment(startPoint, p1, p2)
+			dist := geom.Distance(startPoint, closestOnSeg)
+
+			if dist < minDist {
+				minDist = dist
+				closestFoundPoint = closestOnSeg
+				exist = true
+			}
+		}
+
+		// Check interior rings (holes)
+		for _, hole := range polygon.Holes() {
+			holePoints := hole.Points()
+			for i := 0; i < len(holePoints); i++ {
+				p1 := holePoints[i]
+				p2 := holePoints[(i+1)%len(holePoints)]
+
+				closestOnSeg := closestPointOnSegment(startPoint, p1, p2)
+				dist := geom.Distance(startPoint, closestOnSeg)
+
+				if dist < minDist {
+					minDist = dist
+					closestFoundPoint = closestOnSeg
+					exist = true
+				}
+			}
+		}
+	}
+
+	return closestFoundPoint, exist
+}
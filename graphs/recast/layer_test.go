@@ -0,0 +1,219 @@
+package recast
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bolom009/astar"
+	"github.com/bolom009/geom"
+	"github.com/bolom009/pathfind/graphs"
+	"github.com/bolom009/pathfind/mesh"
+	"github.com/stretchr/testify/assert"
+)
+
+// twoRooms is two squared rooms connected by narrow door with width 4
+var twoRooms = []geom.Vector2{
+	{X: 0, Y: 0}, {X: 40, Y: 0}, {X: 40, Y: 18}, {X: 50, Y: 18},
+	{X: 50, Y: 0}, {X: 90, Y: 0}, {X: 90, Y: 40}, {X: 50, Y: 40},
+	{X: 50, Y: 22}, {X: 40, Y: 22}, {X: 40, Y: 40}, {X: 0, Y: 40},
+}
+
+func TestRecast_AgentRadii(t *testing.T) {
+	recastGraph := NewRecast([]*mesh.Polygon{mesh.NewPolygon(twoRooms, nil, nil, 0)},
+		WithAgentRadii(3, 1, 3), WithTriangleSearch(true))
+	if err := recastGraph.Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, recastGraph.loadLayers(), 3)
+
+	var (
+		start = geom.Vector2{X: 10, Y: 20}
+		dest  = geom.Vector2{X: 80, Y: 20}
+	)
+
+	tests := []struct {
+		name        string
+		agentRadius float32
+		layerRadius float32
+		reachable   bool
+	}{
+		{name: "base layer", agentRadius: 0, layerRadius: 0, reachable: true},
+		{name: "small agent", agentRadius: 0.5, layerRadius: 1, reachable: true},
+		{name: "exact layer", agentRadius: 1, layerRadius: 1, reachable: true},
+		{name: "large agent", agentRadius: 2.5, layerRadius: 3, reachable: false},
+		{name: "too large agent", agentRadius: 10, layerRadius: 10, reachable: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			navOpts := &graphs.NavOpts{AgentRadius: tt.agentRadius}
+			assert.Equal(t, tt.layerRadius, recastGraph.layer(navOpts).agentRadius)
+
+			path, ok := recastGraph.SearchPath(start, dest, navOpts)
+			assert.True(t, ok)
+			assert.Equal(t, tt.reachable, path != nil)
+
+			vis := recastGraph.AggregationGraph(start, dest, navOpts)
+			path = astar.FindPath[geom.Vector2](vis, start, dest, recastGraph.HashIndex, recastGraph.Cost, recastGraph.Cost)
+			assert.Equal(t, tt.reachable, path != nil)
+		})
+	}
+}
+
+func TestRecast_AgentRadiusAboveLayers(t *testing.T) {
+	recastGraph := NewRecast([]*mesh.Polygon{mesh.NewPolygon(twoRooms, nil, nil, 0)}, WithAgentRadii(1))
+	if err := recastGraph.Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		start = geom.Vector2{X: 10, Y: 20}
+		dest  = geom.Vector2{X: 80, Y: 20}
+	)
+
+	// agent fits the door, but its radius isn't baked
+	for _, agentRadius := range []float32{1, 1.5} {
+		navOpts := &graphs.NavOpts{AgentRadius: agentRadius}
+		vis := recastGraph.AggregationGraph(start, dest, navOpts)
+		path := astar.FindPath[geom.Vector2](vis, start, dest, recastGraph.HashIndex, recastGraph.Cost, recastGraph.Cost)
+		assert.Equal(t, agentRadius == 1, path != nil, agentRadius)
+		assert.Equal(t, agentRadius == 1, len(recastGraph.LayerTriangles(agentRadius)) > 0, agentRadius)
+		assert.Equal(t, agentRadius == 1, recastGraph.LineOfSight(navOpts)(start, geom.Vector2{X: 20, Y: 20}), agentRadius)
+	}
+}
+
+func TestRecast_TileSize(t *testing.T) {
+	recastGraph := NewRecast([]*mesh.Polygon{mesh.NewPolygon(twoRooms, nil, nil, 0)},
+		WithTileSize(10), WithTriangleSearch(true))
+	if err := recastGraph.Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		l     = recastGraph.loadLayers()[0]
+		start = geom.Vector2{X: 10, Y: 20}
+		dest  = geom.Vector2{X: 80, Y: 20}
+		door  = mesh.NewObstacle([]geom.Vector2{{X: 42, Y: 15}, {X: 48, Y: 15}, {X: 48, Y: 25}, {X: 42, Y: 25}}, 0, false)
+	)
+
+	assert.Greater(t, len(l.regions), 1)
+	assert.NotNil(t, recastGraph.StraightPath(start, dest))
+
+	var (
+		triangles  = len(l.triangles)
+		visibility = l.visibilityGraph.Copy()
+	)
+
+	ids := recastGraph.AddObstacles(door)
+	assert.Nil(t, recastGraph.StraightPath(start, dest))
+
+	vis := recastGraph.AggregationGraph(start, dest, nil)
+	assert.Nil(t, astar.FindPath[geom.Vector2](vis, start, dest, recastGraph.HashIndex, recastGraph.Cost, recastGraph.Cost))
+
+	cut := 0
+	for _, reg := range recastGraph.loadLayers()[0].regions {
+		if len(reg.obstacles) > 0 {
+			cut++
+		}
+	}
+	assert.Less(t, cut, len(l.regions))
+
+	recastGraph.RemoveObstacles(ids...)
+	assert.NotNil(t, recastGraph.StraightPath(start, dest))
+	l = recastGraph.loadLayers()[0]
+	assert.Equal(t, triangles, len(l.triangles))
+	assert.Equal(t, len(visibility), len(l.visibilityGraph))
+}
+
+func TestRecast_AreaCosts(t *testing.T) {
+	const (
+		road mesh.AreaType = iota + 1
+		mud
+	)
+
+	var (
+		room    = []geom.Vector2{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 60}, {X: 0, Y: 60}}
+		roadWay = []geom.Vector2{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 10}, {X: 0, Y: 10}}
+		swamp   = []geom.Vector2{{X: 30, Y: 10}, {X: 70, Y: 10}, {X: 70, Y: 60}, {X: 30, Y: 60}}
+		start   = geom.Vector2{X: 10, Y: 30}
+		dest    = geom.Vector2{X: 90, Y: 30}
+	)
+
+	recastGraph := NewRecast([]*mesh.Polygon{
+		mesh.NewPolygon(room, nil, nil, 0).AddAreas(mesh.NewArea(roadWay, road), mesh.NewArea(swamp, mud)),
+	}, WithAreaCosts(mesh.AreaCosts{road: 0.5, mud: 5}), WithTriangleSearch(true))
+	if err := recastGraph.Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	areas := make(map[mesh.AreaType]int)
+	for _, reg := range recastGraph.loadLayers()[0].regions {
+		areas[reg.area]++
+	}
+	assert.Equal(t, map[mesh.AreaType]int{mesh.AreaDefault: 2, road: 1, mud: 1}, areas)
+
+	// default area is split by swamp and road, segment goes over default area, swamp and default area again
+	assert.InDelta(t, 40+40*5, recastGraph.Cost(start, dest), 1e-3)
+	assert.InDelta(t, 5, recastGraph.Cost(geom.Vector2{X: 0, Y: 5}, geom.Vector2{X: 10, Y: 5}), 1e-3)
+	assert.InDelta(t, 40, recastGraph.Estimate(start, dest), 1e-3)
+
+	_, ok := recastGraph.SearchPath(start, dest, nil)
+	assert.False(t, ok)
+
+	vis := recastGraph.AggregationGraph(start, dest, nil)
+	path := astar.FindPath[geom.Vector2](vis, start, dest, recastGraph.HashIndex, recastGraph.Cost, recastGraph.Estimate)
+	assert.NotNil(t, path)
+
+	// path goes around the swamp over the road
+	cost := float32(0)
+	for i := 0; i < len(path)-1; i++ {
+		cost += recastGraph.Cost(path[i], path[i+1])
+		if path[i].X >= 30 && path[i].X <= 70 {
+			assert.LessOrEqual(t, path[i].Y, float32(10))
+		}
+	}
+	assert.Less(t, cost, recastGraph.Cost(start, dest))
+
+	// areas are baked
+	data, err := recastGraph.MarshalBinary()
+	assert.NoError(t, err)
+
+	loaded := NewRecast(nil, WithAreaCosts(mesh.AreaCosts{road: 0.5, mud: 5}))
+	assert.NoError(t, loaded.UnmarshalBinary(data))
+	assert.Equal(t, recastGraph.Cost(start, dest), loaded.Cost(start, dest))
+	assert.Equal(t, recastGraph.loadLayers()[0].regions[0].area, loaded.loadLayers()[0].regions[0].area)
+}
+
+func TestRecast_AreaFilters(t *testing.T) {
+	const mud mesh.AreaType = 1
+
+	var (
+		room  = []geom.Vector2{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 60}, {X: 0, Y: 60}}
+		swamp = []geom.Vector2{{X: 30, Y: 0}, {X: 70, Y: 0}, {X: 70, Y: 60}, {X: 30, Y: 60}}
+		start = geom.Vector2{X: 10, Y: 30}
+		dest  = geom.Vector2{X: 90, Y: 30}
+	)
+
+	recastGraph := NewRecast([]*mesh.Polygon{
+		mesh.NewPolygon(room, nil, nil, 0).AddAreas(mesh.NewArea(swamp, mud)),
+	}, WithTileSize(10))
+	if err := recastGraph.Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	navOpts := &graphs.NavOpts{ExcludeAreas: []mesh.AreaType{mud}}
+	for node, neighbours := range recastGraph.GetVisibility(navOpts) {
+		for _, neighbour := range neighbours {
+			middle := node.Lerp(neighbour, 0.5)
+			assert.False(t, middle.X > 30 && middle.X < 70, "%v-%v", node, neighbour)
+		}
+	}
+
+	vis := recastGraph.AggregationGraph(start, dest, navOpts)
+	assert.Nil(t, astar.FindPath[geom.Vector2](vis, start, dest, recastGraph.HashIndex, recastGraph.Cost, recastGraph.Cost))
+
+	navOpts = &graphs.NavOpts{IncludeAreas: []mesh.AreaType{mesh.AreaDefault, mud}}
+	vis = recastGraph.AggregationGraph(start, dest, navOpts)
+	assert.NotNil(t, astar.FindPath[geom.Vector2](vis, start, dest, recastGraph.HashIndex, recastGraph.Cost, recastGraph.Cost))
+}
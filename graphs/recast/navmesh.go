@@ -0,0 +1,644 @@
+package recast
+
+import (
+	"cmp"
+	"container/heap"
+	"math"
+	"slices"
+
+	"github.com/bolom009/geom"
+)
+
+// navMesh represent adjacency of recast triangles
+// each node is triangle index, each edge is shared triangle side (portal)
+type navMesh struct {
+	triangles  []Triangle
+	neighbours [][]int32
+	portals    [][]portal
+	// sides without neighbour, used for joining of meshes
+	open []openSide
+}
+
+// openSide is triangle side which is not shared with other triangle of mesh
+type openSide struct {
+	key  triEdge
+	tri  int32
+	side int
+}
+
+// portal is shared side of two triangles
+// left and right are given by direction of movement through the side
+type portal struct {
+	left  geom.Vector2
+	right geom.Vector2
+}
+
+// overlapEpsilon is minimal length of overlap of sides to be linked
+const overlapEpsilon = 1e-3
+
+// triEdge is an undirected triangle side with ordered points
+// points are stored as float bits, so map key is hashed as plain memory
+type triEdge [4]uint32
+
+func newTriEdge(a, b geom.Vector2) triEdge {
+	if b.X < a.X || (b.X == a.X && b.Y < a.Y) {
+		a, b = b, a
+	}
+
+	// adding zero turns negative zero to positive one
+	return triEdge{
+		math.Float32bits(a.X + 0), math.Float32bits(a.Y + 0),
+		math.Float32bits(b.X + 0), math.Float32bits(b.Y + 0),
+	}
+}
+
+func buildNavMesh(triangles []Triangle) *navMesh {
+	m := &navMesh{
+		triangles:  triangles,
+		neighbours: make([][]int32, len(triangles)),
+		portals:    make([][]portal, len(triangles)),
+	}
+
+	owners := make(map[triEdge]openSide, len(triangles)*3/2)
+	for i, triangle := range triangles {
+		for j := 0; j < 3; j++ {
+			key := newTriEdge(triangle[j], triangle[(j+1)%3])
+			if other, ok := owners[key]; ok {
+				m.link(int32(i), j, other.tri)
+				delete(owners, key)
+				continue
+			}
+
+			owners[key] = openSide{key: key, tri: int32(i), side: j}
+		}
+	}
+
+	m.open = make([]openSide, 0, len(owners))
+	for i, triangle := range triangles {
+		for j := 0; j < 3; j++ {
+			if owner, ok := owners[newTriEdge(triangle[j], triangle[(j+1)%3])]; ok && owner.tri == int32(i) && owner.side == j {
+				m.open = append(m.open, owner)
+			}
+		}
+	}
+
+	return m
+}
+
+// seam link points of overlapped sides of triangles from different parts
+// tris are triangles which sides contain the seam (the same triangle twice if seam is out of overlap)
+type seam struct {
+	a, b geom.Vector2
+	tris [2]int32
+}
+
+// joinNavMeshes concatenate meshes and link their open sides
+// triangles of each part are shifted by count of triangles of previous parts.
+// Axis aligned sides are linked by overlap (tile borders may have vertices only on one side),
+// such links are returned as seams between vertices of different parts
+func joinNavMeshes(parts []*navMesh) (*navMesh, []seam) {
+	triLen := 0
+	for _, part := range parts {
+		triLen += len(part.triangles)
+	}
+
+	m := &navMesh{
+		triangles:  make([]Triangle, 0, triLen),
+		neighbours: make([][]int32, 0, triLen),
+		portals:    make([][]portal, 0, triLen),
+	}
+
+	// each triangle has up to 3 neighbours, so all of them are kept in one buffer
+	var (
+		buf       = make([]int32, triLen*3)
+		owners    = make(map[triEdge]openSide)
+		lines     = make(map[axisLine][]openSide)
+		lineOrder = make([]axisLine, 0)
+	)
+
+	for _, part := range parts {
+		offset := int32(len(m.triangles))
+		m.triangles = append(m.triangles, part.triangles...)
+		for i := range part.triangles {
+			idx := (int(offset) + i) * 3
+			neighbours := buf[idx : idx : idx+3]
+			for _, neighbour := range part.neighbours[i] {
+				neighbours = append(neighbours, neighbour+offset)
+			}
+
+			m.neighbours = append(m.neighbours, neighbours)
+			// clip capacity so linking of open sides doesn't write to part portals
+			m.portals = append(m.portals, slices.Clip(part.portals[i]))
+		}
+
+		for _, side := range part.open {
+			side.tri += offset
+
+			p, q := m.sidePoints(side)
+			if line, ok := newAxisLine(p, q); ok {
+				if _, ok := lines[line]; !ok {
+					lineOrder = append(lineOrder, line)
+				}
+
+				lines[line] = append(lines[line], side)
+				continue
+			}
+
+			if other, ok := owners[side.key]; ok {
+				m.link(side.tri, side.side, other.tri)
+				delete(owners, side.key)
+				continue
+			}
+
+			owners[side.key] = side
+		}
+	}
+
+	seams := make([]seam, 0)
+	for _, line := range lineOrder {
+		seams = m.linkLine(line, lines[line], seams)
+	}
+
+	return m, seams
+}
+
+// axisLine is vertical (x = coord) or horizontal (y = coord) line
+type axisLine struct {
+	vertical bool
+	coord    float32
+}
+
+func newAxisLine(p, q geom.Vector2) (axisLine, bool) {
+	switch {
+	case p.X == q.X:
+		return axisLine{vertical: true, coord: p.X + 0}, true
+	case p.Y == q.Y:
+		return axisLine{vertical: false, coord: p.Y + 0}, true
+	}
+
+	return axisLine{}, false
+}
+
+// along return position of point on line
+func (l axisLine) along(p geom.Vector2) float32 {
+	if l.vertical {
+		return p.Y
+	}
+
+	return p.X
+}
+
+// clamp move point on line to be in range lo..hi
+func (l axisLine) clamp(p geom.Vector2, lo, hi float32) geom.Vector2 {
+	if l.vertical {
+		p.Y = min(max(p.Y, lo), hi)
+	} else {
+		p.X = min(max(p.X, lo), hi)
+	}
+
+	return p
+}
+
+func (m *navMesh) sidePoints(side openSide) (geom.Vector2, geom.Vector2) {
+	triangle := m.triangles[side.tri]
+	return triangle[side.side], triangle[(side.side+1)%3]
+}
+
+// sideRange return range of side on line
+func (m *navMesh) sideRange(line axisLine, side openSide) (float32, float32) {
+	p, q := m.sidePoints(side)
+	a, b := line.along(p), line.along(q)
+	return min(a, b), max(a, b)
+}
+
+// linkLine link overlapped sides which lie on the same line
+// and append seams between their points to make vertices of both sides connected
+func (m *navMesh) linkLine(line axisLine, sides []openSide, seams []seam) []seam {
+	slices.SortFunc(sides, func(a, b openSide) int {
+		aLo, _ := m.sideRange(line, a)
+		bLo, _ := m.sideRange(line, b)
+		return cmp.Compare(aLo, bLo)
+	})
+
+	for i, a := range sides {
+		aLo, aHi := m.sideRange(line, a)
+		for _, b := range sides[i+1:] {
+			bLo, bHi := m.sideRange(line, b)
+			if bLo >= aHi {
+				break
+			}
+
+			lo, hi := max(aLo, bLo), min(aHi, bHi)
+			if hi-lo < overlapEpsilon || a.tri == b.tri {
+				continue
+			}
+
+			aLeft, aRight := sidePortal(m.triangles[a.tri], a.side)
+			bLeft, bRight := sidePortal(m.triangles[b.tri], b.side)
+			m.neighbours[a.tri] = append(m.neighbours[a.tri], b.tri)
+			m.portals[a.tri] = append(m.portals[a.tri], portal{left: line.clamp(aLeft, lo, hi), right: line.clamp(aRight, lo, hi)})
+			m.neighbours[b.tri] = append(m.neighbours[b.tri], a.tri)
+			m.portals[b.tri] = append(m.portals[b.tri], portal{left: line.clamp(bLeft, lo, hi), right: line.clamp(bRight, lo, hi)})
+
+			seams = m.appendSeams(seams, line, a, b)
+		}
+	}
+
+	return seams
+}
+
+// appendSeams connect sorted points of two overlapped sides one by one
+func (m *navMesh) appendSeams(seams []seam, line axisLine, a, b openSide) []seam {
+	ap, aq := m.sidePoints(a)
+	bp, bq := m.sidePoints(b)
+
+	points := []geom.Vector2{ap, aq, bp, bq}
+	slices.SortFunc(points, func(p, q geom.Vector2) int {
+		return cmp.Compare(line.along(p), line.along(q))
+	})
+
+	aLo, aHi := m.sideRange(line, a)
+	for i := 0; i < len(points)-1; i++ {
+		if points[i] == points[i+1] {
+			continue
+		}
+
+		// part of seam out of overlap lies only on one of sides
+		tris := [2]int32{a.tri, b.tri}
+		if middle := (line.along(points[i]) + line.along(points[i+1])) / 2; middle < aLo || middle > aHi {
+			tris[0] = b.tri
+		} else if bLo, bHi := m.sideRange(line, b); middle < bLo || middle > bHi {
+			tris[1] = a.tri
+		}
+
+		seams = append(seams, seam{a: points[i], b: points[i+1], tris: tris})
+	}
+
+	return seams
+}
+
+// link make triangles i and other neighbours through side j of triangle i
+func (m *navMesh) link(i int32, j int, other int32) {
+	left, right := sidePortal(m.triangles[i], j)
+	m.neighbours[i] = append(m.neighbours[i], other)
+	m.portals[i] = append(m.portals[i], portal{left: left, right: right})
+	m.neighbours[other] = append(m.neighbours[other], i)
+	m.portals[other] = append(m.portals[other], portal{left: right, right: left})
+}
+
+// Neighbours returns the triangles which share side with triangle i
+// This method makes navMesh implement the astar.Graph[int32] interface.
+func (m *navMesh) Neighbours(i int32) []int32 {
+	return m.neighbours[i]
+}
+
+// corridor return list of triangles from start triangle to dest triangle, it's found by A* over portals
+// Triangle is entered at point of portal which is on straight line to dest or at the closest portal end,
+// so cost of corridor is length of path through entry points which is close to length of path pulled through it
+func (m *navMesh) corridor(start, dest geom.Vector2, startTri, destTri int32) []int32 {
+	if startTri == destTri {
+		return []int32{startTri}
+	}
+
+	var (
+		dist   = map[int32]float32{startTri: 0}
+		entry  = map[int32]geom.Vector2{startTri: start}
+		prev   = make(map[int32]int32)
+		closed = make(map[int32]struct{})
+		open   = &triQueue{{tri: startTri, priority: geom.Distance(start, dest)}}
+	)
+
+	for open.Len() > 0 {
+		current := heap.Pop(open).(queueTri).tri
+		if _, ok := closed[current]; ok {
+			continue
+		}
+
+		if current == destTri {
+			corridor := []int32{destTri}
+			for current != startTri {
+				current = prev[current]
+				corridor = append(corridor, current)
+			}
+
+			slices.Reverse(corridor)
+			return corridor
+		}
+
+		closed[current] = struct{}{}
+		for i, neighbour := range m.neighbours[current] {
+			if _, ok := closed[neighbour]; ok {
+				continue
+			}
+
+			point := portalPoint(entry[current], dest, m.portals[current][i])
+			d := dist[current] + geom.Distance(entry[current], point)
+			if old, ok := dist[neighbour]; !ok || d < old {
+				dist[neighbour], entry[neighbour], prev[neighbour] = d, point, current
+				heap.Push(open, queueTri{tri: neighbour, priority: d + geom.Distance(point, dest)})
+			}
+		}
+	}
+
+	return nil
+}
+
+// portalPoint return point where segment from-to crosses portal or portal end with the shortest way through it
+func portalPoint(from, to geom.Vector2, p portal) geom.Vector2 {
+	var (
+		l = triArea2(from, to, p.left)
+		r = triArea2(from, to, p.right)
+	)
+
+	if l != r && (l <= 0 && r >= 0 || l >= 0 && r <= 0) {
+		return p.left.Lerp(p.right, l/(l-r))
+	}
+
+	if geom.Distance(from, p.left)+geom.Distance(p.left, to) < geom.Distance(from, p.right)+geom.Distance(p.right, to) {
+		return p.left
+	}
+
+	return p.right
+}
+
+// sleeve return corridor of adjacent triangles from start triangle to dest triangle which path goes through
+// Path could pass from triangle to not adjacent one by point where they touch, such triangles are joined
+// by triangles around the point, so path lies in sleeve and funnel usually makes it shorter
+func (m *navMesh) sleeve(path []geom.Vector2, startTri, destTri int32) []int32 {
+	sleeve := []int32{startTri}
+	join := func(tri int32, a, b geom.Vector2) bool {
+		last := sleeve[len(sleeve)-1]
+		if _, _, ok := m.portal(last, tri); !ok && last != tri {
+			bridge := m.bridge(last, tri, a, b)
+			if bridge == nil {
+				return false
+			}
+
+			for _, t := range bridge[1 : len(bridge)-1] {
+				sleeve = appendTriangle(sleeve, t)
+			}
+		}
+
+		sleeve = appendTriangle(sleeve, tri)
+		return true
+	}
+
+	a, b := path[0], path[len(path)-1]
+	for i := 0; i < len(path)-1; i++ {
+		a, b = path[i], path[i+1]
+		for _, tri := range m.segmentCorridor(a, b, nil) {
+			if !join(tri, a, b) {
+				return nil
+			}
+		}
+	}
+
+	if !join(destTri, a, b) {
+		return nil
+	}
+
+	return sleeve
+}
+
+// appendTriangle add triangle to sleeve, if sleeve already has it then loop after it is dropped
+func appendTriangle(sleeve []int32, tri int32) []int32 {
+	if i := slices.Index(sleeve, tri); i >= 0 {
+		return sleeve[:i+1]
+	}
+
+	return append(sleeve, tri)
+}
+
+// bridge return triangles from a to b around point where they touch, triangles of joined regions could touch
+// by vertex which lies on side of other triangle. Triangles which don't touch (segment from-to goes along outline
+// and walk over triangles misses them) are joined by search over portals along the segment
+func (m *navMesh) bridge(a, b int32, from, to geom.Vector2) []int32 {
+	point, ok := m.touchPoint(a, b)
+	if !ok {
+		return m.corridor(from, to, a, b)
+	}
+
+	prev := map[int32]int32{a: a}
+	for queue := []int32{a}; len(queue) > 0; queue = queue[1:] {
+		current := queue[0]
+		if current == b {
+			bridge := []int32{b}
+			for current != a {
+				current = prev[current]
+				bridge = append(bridge, current)
+			}
+
+			slices.Reverse(bridge)
+			return bridge
+		}
+
+		for _, neighbour := range m.neighbours[current] {
+			if _, ok := prev[neighbour]; !ok && m.touches(neighbour, point) {
+				prev[neighbour] = current
+				queue = append(queue, neighbour)
+			}
+		}
+	}
+
+	return nil
+}
+
+// touchPoint return vertex of one triangle which lies on other triangle
+func (m *navMesh) touchPoint(a, b int32) (geom.Vector2, bool) {
+	for _, pair := range [2][2]int32{{a, b}, {b, a}} {
+		for _, vertex := range m.triangles[pair[0]] {
+			if m.touches(pair[1], vertex) {
+				return vertex, true
+			}
+		}
+	}
+
+	return geom.Vector2{}, false
+}
+
+// touches checks if point is inside triangle or closer than overlapEpsilon to its sides
+func (m *navMesh) touches(tri int32, point geom.Vector2) bool {
+	if m.containsPoint(tri, point) {
+		return true
+	}
+
+	triangle := m.triangles[tri]
+	for j := 0; j < 3; j++ {
+		if geom.Distance(point, closestPointOnSegment(point, triangle[j], triangle[(j+1)%3])) < overlapEpsilon {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pathCorridor return triangles which path segments go through
+// parts of path out of mesh are skipped
+func (m *navMesh) pathCorridor(path []geom.Vector2) []int32 {
+	corridor := make([]int32, 0, len(path))
+	for i := 0; i < len(path)-1; i++ {
+		corridor = m.segmentCorridor(path[i], path[i+1], corridor)
+	}
+
+	return corridor
+}
+
+// corridorStep is distance to move over triangle side during walk along segment
+const corridorStep = 1e-3
+
+// segmentCorridor walk along segment a-b from triangle to triangle and append them to corridor
+func (m *navMesh) segmentCorridor(a, b geom.Vector2, corridor []int32) []int32 {
+	length := geom.Distance(a, b)
+	if length == 0 {
+		return corridor
+	}
+
+	var (
+		step = corridorStep / length
+		tri  = int32(-1)
+	)
+
+	for t, steps := float32(0), 0; t < 1 && steps <= len(m.triangles); steps++ {
+		next, ok := m.nextTriangle(tri, a.Lerp(b, min(t+step, 1)))
+		if !ok {
+			return corridor
+		}
+
+		if len(corridor) == 0 || corridor[len(corridor)-1] != next {
+			corridor = append(corridor, next)
+		}
+
+		tri = next
+		t = max(m.exitParam(tri, a, b), t+step)
+	}
+
+	return corridor
+}
+
+// nextTriangle return triangle which contains point, neighbours of triangle tri are checked first
+func (m *navMesh) nextTriangle(tri int32, point geom.Vector2) (int32, bool) {
+	if tri >= 0 {
+		if m.containsPoint(tri, point) {
+			return tri, true
+		}
+
+		for _, idx := range m.neighbours[tri] {
+			if m.containsPoint(idx, point) {
+				return idx, true
+			}
+		}
+	}
+
+	return m.findTriangle(point)
+}
+
+func (m *navMesh) containsPoint(tri int32, point geom.Vector2) bool {
+	triangle := m.triangles[tri]
+	return pointInsideTriangle(triangle[0], triangle[1], triangle[2], point)
+}
+
+// exitParam return parameter of segment a-b where it leaves triangle (Cyrus-Beck clipping)
+func (m *navMesh) exitParam(tri int32, a, b geom.Vector2) float32 {
+	var (
+		triangle = m.triangles[tri]
+		dir      = b.Sub(a)
+		sign     = float32(1)
+		tOut     = float32(1)
+	)
+
+	if triArea2(triangle[0], triangle[1], triangle[2]) < 0 {
+		sign = -1
+	}
+
+	for j := 0; j < 3; j++ {
+		p, q := triangle[j], triangle[(j+1)%3]
+		edge := q.Sub(p)
+
+		// point is inside while side distance is not negative
+		dist := sign * (edge.X*(a.Y-p.Y) - edge.Y*(a.X-p.X))
+		speed := sign * (edge.X*dir.Y - edge.Y*dir.X)
+		if speed < 0 {
+			tOut = min(tOut, -dist/speed)
+		}
+	}
+
+	return tOut
+}
+
+// portal return shared side of triangles from and to
+// left and right are given by direction of movement from triangle from to triangle to
+func (m *navMesh) portal(from, to int32) (geom.Vector2, geom.Vector2, bool) {
+	for i, neighbour := range m.neighbours[from] {
+		if neighbour == to {
+			p := m.portals[from][i]
+			return p.left, p.right, true
+		}
+	}
+
+	return geom.Vector2{}, geom.Vector2{}, false
+}
+
+// sidePortal return left and right points of triangle side i when we move out of triangle through it
+func sidePortal(triangle Triangle, i int) (geom.Vector2, geom.Vector2) {
+	p, q := triangle[i], triangle[(i+1)%3]
+
+	// counterclockwise triangle has third vertex on the left side of p->q,
+	// so moving out through p->q the point q is on the left
+	if triArea2(p, q, triangle[(i+2)%3]) > 0 {
+		return q, p
+	}
+
+	return p, q
+}
+
+// findTriangle return index of triangle which contains point
+func (m *navMesh) findTriangle(point geom.Vector2) (int32, bool) {
+	for i, triangle := range m.triangles {
+		if pointInsideTriangle(triangle[0], triangle[1], triangle[2], point) {
+			return int32(i), true
+		}
+	}
+
+	return -1, false
+}
+
+// closestTriangle return index of triangle closest to point and closest point on its sides
+func (m *navMesh) closestTriangle(point geom.Vector2) (int32, geom.Vector2, bool) {
+	var (
+		minDist      = float32(math.MaxFloat32)
+		closestPoint = geom.Vector2{}
+		closestIdx   = int32(-1)
+	)
+
+	for i, triangle := range m.triangles {
+		for j := 0; j < 3; j++ {
+			p := closestPointOnSegment(point, triangle[j], triangle[(j+1)%3])
+			if dist := geom.Distance(point, p); dist < minDist {
+				minDist = dist
+				closestPoint = p
+				closestIdx = int32(i)
+			}
+		}
+	}
+
+	return closestIdx, closestPoint, closestIdx >= 0
+}
+
+type queueTri struct {
+	tri      int32
+	priority float32
+}
+
+// triQueue is min-heap of triangles by priority
+type triQueue []queueTri
+
+func (q triQueue) Len() int           { return len(q) }
+func (q triQueue) Less(i, j int) bool { return q[i].priority < q[j].priority }
+func (q triQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *triQueue) Push(x any)        { *q = append(*q, x.(queueTri)) }
+func (q *triQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
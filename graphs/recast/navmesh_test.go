@@ -0,0 +1,138 @@
+package recast
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/bolom009/astar"
+	"github.com/bolom009/geom"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_buildNavMesh(t *testing.T) {
+	var (
+		a = geom.Vector2{X: 0, Y: 0}
+		b = geom.Vector2{X: 10, Y: 0}
+		c = geom.Vector2{X: 10, Y: 10}
+		d = geom.Vector2{X: 0, Y: 10}
+		e = geom.Vector2{X: 20, Y: 10}
+	)
+
+	m := buildNavMesh([]Triangle{{a, b, c}, {a, c, d}, {b, e, c}})
+
+	assert.ElementsMatch(t, []int32{1, 2}, m.Neighbours(0))
+	assert.Equal(t, []int32{0}, m.Neighbours(1))
+	assert.Equal(t, []int32{0}, m.Neighbours(2))
+
+	// move from right-bottom triangle to left-top triangle through diagonal a-c
+	left, right, ok := m.portal(0, 1)
+	assert.True(t, ok)
+	assert.Equal(t, a, left)
+	assert.Equal(t, c, right)
+
+	left, right, ok = m.portal(1, 0)
+	assert.True(t, ok)
+	assert.Equal(t, c, left)
+	assert.Equal(t, a, right)
+
+	_, _, ok = m.portal(1, 2)
+	assert.False(t, ok)
+
+	assert.Equal(t, []int32{1, 0, 2}, m.corridor(geom.Vector2{X: 2, Y: 8}, geom.Vector2{X: 15, Y: 8}, 1, 2))
+}
+
+func TestRecast_SearchPath(t *testing.T) {
+	rPolygons, err := loadLargeLocation()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		start = geom.Vector2{X: 202, Y: -268}
+		dest  = geom.Vector2{X: -4, Y: 84}
+	)
+
+	recastGraph := NewRecast(rPolygons)
+	_ = recastGraph.Generate(nil)
+
+	path, ok := recastGraph.SearchPath(start, dest, nil)
+	assert.False(t, ok)
+	assert.Nil(t, path)
+
+	recastGraph = NewRecast(rPolygons, WithTriangleSearch(true))
+	_ = recastGraph.Generate(nil)
+
+	path, ok = recastGraph.SearchPath(start, dest, nil)
+	assert.True(t, ok)
+	assert.Equal(t, start, path[0])
+	assert.Equal(t, dest, path[len(path)-1])
+}
+
+func TestRecast_SearchPathLength(t *testing.T) {
+	rPolygons, err := loadLargeLocation()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recastGraph := NewRecast(rPolygons, WithTriangleSearch(true))
+	if err = recastGraph.Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		rnd                     = rand.New(rand.NewSource(1))
+		triangleSum, defaultSum float32
+	)
+
+	for range 500 {
+		start, dest := randomMeshPoint(recastGraph, rnd), randomMeshPoint(recastGraph, rnd)
+		vis := recastGraph.AggregationGraph(start, dest, nil)
+		path := astar.FindPath[geom.Vector2](vis, start, dest, recastGraph.HashIndex, recastGraph.Cost, recastGraph.Estimate)
+		if path == nil {
+			continue
+		}
+
+		trianglePath, ok := recastGraph.SearchPath(start, dest, nil)
+		assert.True(t, ok)
+		assert.Equal(t, start, trianglePath[0])
+		assert.Equal(t, dest, trianglePath[len(trianglePath)-1])
+		assert.LessOrEqual(t, pathLength(trianglePath), pathLength(path)*1.2, [2]geom.Vector2{start, dest})
+
+		triangleSum += pathLength(trianglePath)
+		defaultSum += pathLength(path)
+	}
+
+	// triangle search pulls path through corridor, so it's usually shorter than path over visibility graph
+	assert.Less(t, triangleSum, defaultSum)
+}
+
+func Test_navMesh_pathCorridor(t *testing.T) {
+	var (
+		a = geom.Vector2{X: 0, Y: 0}
+		b = geom.Vector2{X: 10, Y: 0}
+		c = geom.Vector2{X: 10, Y: 10}
+		d = geom.Vector2{X: 0, Y: 10}
+		e = geom.Vector2{X: 20, Y: 10}
+	)
+
+	m := buildNavMesh([]Triangle{{a, b, c}, {a, c, d}, {b, e, c}})
+
+	tests := []struct {
+		name string
+		path []geom.Vector2
+		want []int32
+	}{
+		{name: "inside triangle", path: []geom.Vector2{{X: 1, Y: 8}, {X: 2, Y: 8}}, want: []int32{1}},
+		{name: "cross triangles", path: []geom.Vector2{{X: 1, Y: 8}, {X: 12, Y: 9}}, want: []int32{1, 0, 2}},
+		{name: "along side", path: []geom.Vector2{a, c}, want: []int32{0}},
+		{name: "polyline", path: []geom.Vector2{{X: 1, Y: 8}, {X: 8, Y: 2}, {X: 12, Y: 9}}, want: []int32{1, 0, 2}},
+		{name: "out of mesh", path: []geom.Vector2{{X: -5, Y: 5}, {X: 0, Y: 5}, {X: 1, Y: 8}}, want: []int32{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, m.pathCorridor(tt.path))
+		})
+	}
+}
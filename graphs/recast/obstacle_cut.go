@@ -0,0 +1,156 @@
+package recast
+
+import (
+	"github.com/bolom009/geom"
+	goclipper2 "github.com/bolom009/go-clipper2"
+	"github.com/bolom009/pathfind/graphs"
+	"github.com/bolom009/pathfind/obstacles"
+)
+
+// queryObstacles return polygons of per-query obstacles inflated by agent radius
+func queryObstacles(navOpts *graphs.NavOpts) [][]geom.Vector2 {
+	if navOpts == nil || len(navOpts.Obstacles) == 0 {
+		return nil
+	}
+
+	polygons := make([][]geom.Vector2, 0, len(navOpts.Obstacles))
+	for _, obstacle := range navOpts.Obstacles {
+		polygons = append(polygons, inflateObstacle(obstacle, navOpts.AgentRadius)...)
+	}
+
+	return polygons
+}
+
+// inflateObstacle return obstacle polygon offset by agent radius
+func inflateObstacle(obstacle obstacles.Obstacle, agentRadius float32) [][]geom.Vector2 {
+	points := obstacle.GetPolygon()
+	if agentRadius <= 0 {
+		return [][]geom.Vector2{points}
+	}
+
+	path := toPathD(points)
+	if !goclipper2.IsPositiveD(path) {
+		path = toPathD(goclipper2.ReversePath(points))
+	}
+
+	paths := inflatePathsD(goclipper2.PathsD{path}, float64(agentRadius), goclipper2.Miter, goclipper2.Polygon)
+	polygons := make([][]geom.Vector2, 0, len(paths))
+	for _, p := range paths {
+		polygons = append(polygons, toPoint2(p))
+	}
+
+	return polygons
+}
+
+// cutGraphWithObstacles delete vertices & edges of graph which are blocked by obstacle polygons
+// extra nodes are checked in addition to triangle vertices (start, dest, out of area points)
+func (l *layer) cutGraphWithObstacles(vis graphs.Graph[geom.Vector2], polygons [][]geom.Vector2, extra ...geom.Vector2) {
+	for _, polygon := range polygons {
+		var (
+			box     = getBoundingBox(polygon)
+			checked = make(map[geom.Vector2]struct{})
+		)
+
+		for _, node := range extra {
+			cutNodeWithObstacle(vis, node, polygon, box, checked)
+		}
+
+		for _, triangle := range l.triangles {
+			if !boundingBoxesOverlap(getBoundingBox(triangle[:]), box) {
+				continue
+			}
+
+			for _, node := range triangle {
+				cutNodeWithObstacle(vis, node, polygon, box, checked)
+			}
+		}
+	}
+}
+
+func cutNodeWithObstacle(vis graphs.Graph[geom.Vector2], node geom.Vector2, polygon []geom.Vector2, box BoundingBox, checked map[geom.Vector2]struct{}) {
+	if _, ok := checked[node]; ok {
+		return
+	}
+	checked[node] = struct{}{}
+
+	neighbours, ok := vis[node]
+	if !ok {
+		return
+	}
+
+	if pointInPolygon(node, polygon) {
+		for _, neighbour := range neighbours {
+			vis.DeleteNeighbour(neighbour, node)
+		}
+
+		vis.DeleteNode(node)
+		return
+	}
+
+	for _, neighbour := range neighbours {
+		if !lineIntersectsBoundingBox(node, neighbour, box) {
+			continue
+		}
+
+		if pointInPolygon(neighbour, polygon) || isLineSegmentInsidePolygon(polygon, node, neighbour) {
+			vis.DeleteNeighbour(node, neighbour)
+			vis.DeleteNeighbour(neighbour, node)
+		}
+	}
+}
+
+// isSegmentBlocked checks if segment intersects or lies inside any of polygons
+func isSegmentBlocked(polygons [][]geom.Vector2, a, b geom.Vector2) bool {
+	for _, polygon := range polygons {
+		if pointInPolygon(a, polygon) || pointInPolygon(b, polygon) || isLineSegmentInsidePolygon(polygon, a, b) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func boundingBoxesOverlap(a, b BoundingBox) bool {
+	return a.MinX <= b.MaxX && a.MaxX >= b.MinX && a.MinY <= b.MaxY && a.MaxY >= b.MinY
+}
+
+// cutGraphWithAreas delete edges of graph which lie only in triangles of areas forbidden by navOpts
+// edges and seams on borders of allowed areas are kept, so path could go along forbidden area
+func (l *layer) cutGraphWithAreas(vis graphs.Graph[geom.Vector2], navOpts *graphs.NavOpts) {
+	var (
+		blocked  = make(map[triEdge]int32)
+		segments = make([][2]geom.Vector2, 0)
+	)
+
+	block := func(a, b geom.Vector2) {
+		blocked[newTriEdge(a, b)]++
+		segments = append(segments, [2]geom.Vector2{a, b})
+	}
+
+	for i, triangle := range l.triangles {
+		if navOpts.AreaAllowed(l.triAreas[i]) {
+			continue
+		}
+
+		for j := 0; j < 3; j++ {
+			block(triangle[j], triangle[(j+1)%3])
+		}
+	}
+
+	for _, seam := range l.seams {
+		if !navOpts.AreaAllowed(l.triAreas[seam.tris[0]]) && !navOpts.AreaAllowed(l.triAreas[seam.tris[1]]) {
+			block(seam.a, seam.b)
+		}
+	}
+
+	// segment is deleted when all triangles and seams which link it are blocked
+	for _, segment := range segments {
+		a, b := segment[0], segment[1]
+		if blocked[newTriEdge(a, b)] < l.edgeRefs[newTriEdge(a, b)] {
+			continue
+		}
+
+		vis.DeleteNeighbour(a, b)
+		vis.DeleteNeighbour(b, a)
+	}
+}
@@ -0,0 +1,236 @@
+package recast
+
+import (
+	"slices"
+
+	"github.com/bolom009/geom"
+	"github.com/bolom009/pathfind/graphs"
+	"github.com/bolom009/pathfind/graphs/internal"
+)
+
+// OffMeshLink connects points which can't be reached by walking over mesh (jumps, ladders, teleporters)
+// Points could be in different polygons. Cost is cost of link traversal, if it's zero cost function is used.
+// Meta is user data which is returned with path (e.g. animation to play)
+type OffMeshLink struct {
+	Start         geom.Vector2
+	End           geom.Vector2
+	Bidirectional bool
+	Cost          float32
+	Meta          any
+}
+
+// linkEdge is directed edge of graph made by off-mesh link
+type linkEdge struct {
+	from geom.Vector2
+	to   geom.Vector2
+}
+
+// offMeshLinks is immutable set of registered links, it's shared by published layers
+type offMeshLinks struct {
+	ids   []uint32
+	items []OffMeshLink
+	// directed edge to index of link
+	edges map[linkEdge]int
+}
+
+func newOffMeshLinks(registry map[uint32]OffMeshLink) *offMeshLinks {
+	links := &offMeshLinks{
+		ids:   make([]uint32, 0, len(registry)),
+		items: make([]OffMeshLink, 0, len(registry)),
+		edges: make(map[linkEdge]int, len(registry)*2),
+	}
+
+	for id := range registry {
+		links.ids = append(links.ids, id)
+	}
+	slices.Sort(links.ids)
+
+	for i, id := range links.ids {
+		link := registry[id]
+		links.items = append(links.items, link)
+		links.edges[linkEdge{from: link.Start, to: link.End}] = i
+		if link.Bidirectional {
+			links.edges[linkEdge{from: link.End, to: link.Start}] = i
+		}
+	}
+
+	return links
+}
+
+// AddOffMeshLinks register off-mesh links and return their ids
+// Link points which are out of layer triangles are ignored by this layer.
+// Links are runtime data like extra obstacles, they could be added before or after Generate
+func (r *Recast) AddOffMeshLinks(links ...OffMeshLink) []uint32 {
+	if len(links) == 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]uint32, len(links))
+	for i, link := range links {
+		r.nextLinkID++
+		ids[i] = r.nextLinkID
+		r.linkRegistry[ids[i]] = link
+	}
+
+	r.publishLinks()
+	return ids
+}
+
+// RemoveOffMeshLinks unregister off-mesh links by ids
+func (r *Recast) RemoveOffMeshLinks(ids ...uint32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range ids {
+		delete(r.linkRegistry, id)
+	}
+
+	r.publishLinks()
+}
+
+// publishLinks make copies of layers with new set of links
+func (r *Recast) publishLinks() {
+	var (
+		layers = r.loadLayers()
+		next   = make([]*layer, len(layers))
+	)
+
+	r.links = newOffMeshLinks(r.linkRegistry)
+	for i, l := range layers {
+		c := *l
+		c.prepareLinks(r.links)
+		next[i] = &c
+	}
+
+	r.layers.Store(&next)
+	r.changes.Record(internal.Change[BoundingBox]{Full: true})
+}
+
+// PathLinks return off-mesh links which path goes through
+// This method makes Recast implement the graphs.PathLinker interface.
+func (r *Recast) PathLinks(path []geom.Vector2, navOpts *graphs.NavOpts) []graphs.PathLink {
+	l := r.layer(navOpts)
+	if !l.hasLinks() {
+		return nil
+	}
+
+	links := l.links
+
+	var pathLinks []graphs.PathLink
+	for i := 0; i < len(path)-1; i++ {
+		if idx, ok := links.edges[linkEdge{from: path[i], to: path[i+1]}]; ok {
+			pathLinks = append(pathLinks, graphs.PathLink{Segment: i, ID: links.ids[idx], Meta: links.items[idx].Meta})
+		}
+	}
+
+	return pathLinks
+}
+
+// linkCost return cost of off-mesh link if a-b is link
+func (r *Recast) linkCost(a, b geom.Vector2) (float32, bool) {
+	l := r.loadLayers()[0]
+	if !l.hasLinks() {
+		return 0, false
+	}
+
+	links := l.links
+
+	idx, ok := links.edges[linkEdge{from: a, to: b}]
+	if !ok {
+		return 0, false
+	}
+
+	// link doesn't go over areas, so their costs aren't applied
+	if links.items[idx].Cost <= 0 {
+		return r.costFunc(a, b), true
+	}
+
+	return links.items[idx].Cost, true
+}
+
+// prepareLinks find triangles of layer which contain points of links
+func (l *layer) prepareLinks(links *offMeshLinks) {
+	l.links = links
+	l.linkTris = make([][2]int32, len(links.items))
+	for i, link := range links.items {
+		l.linkTris[i] = [2]int32{l.findLinkTriangle(link.Start), l.findLinkTriangle(link.End)}
+	}
+}
+
+func (l *layer) findLinkTriangle(point geom.Vector2) int32 {
+	if l.navMesh == nil {
+		return -1
+	}
+
+	tri, ok := l.navMesh.findTriangle(point)
+	if !ok {
+		return -1
+	}
+
+	return tri
+}
+
+func (l *layer) hasLinks() bool {
+	return l.links != nil && len(l.links.items) > 0
+}
+
+// linkOffMesh add off-mesh links to graph, link points are linked with vertices of triangles which contain them
+// and with query points (start, dest) which are visible from them. Linked points of links are returned,
+// links which points are in areas forbidden by navOpts are skipped
+func (l *layer) linkOffMesh(vis graphs.Graph[geom.Vector2], navOpts *graphs.NavOpts, queryPoints ...geom.Vector2) []geom.Vector2 {
+	if !l.hasLinks() {
+		return nil
+	}
+
+	points := make([]geom.Vector2, 0, len(l.links.items)*2)
+	for i, link := range l.links.items {
+		tris := l.linkTris[i]
+		if tris[0] < 0 || tris[1] < 0 || !navOpts.AreaAllowed(l.triAreas[tris[0]]) || !navOpts.AreaAllowed(l.triAreas[tris[1]]) {
+			continue
+		}
+
+		l.linkPoint(vis, link.Start, tris[0], queryPoints)
+		l.linkPoint(vis, link.End, tris[1], queryPoints)
+
+		vis.Link(link.Start, link.End)
+		if link.Bidirectional {
+			vis.Link(link.End, link.Start)
+		}
+
+		points = append(points, link.Start, link.End)
+	}
+
+	return points
+}
+
+func (l *layer) linkPoint(vis graphs.Graph[geom.Vector2], point geom.Vector2, tri int32, queryPoints []geom.Vector2) {
+	for _, v := range l.triangles[tri] {
+		if v != point {
+			vis.LinkBoth(point, v)
+		}
+	}
+
+	for _, v := range queryPoints {
+		if v != point && l.isVisible(point, v) {
+			vis.LinkBoth(point, v)
+		}
+	}
+}
+
+// isVisible checks if segment a-b lies inside one of clipped polygons
+func (l *layer) isVisible(a, b geom.Vector2) bool {
+	for _, polygon := range l.extraClippedPolygons {
+		polyPoints := polygon.Points()
+		polyHoles := polygon.Holes()
+		if isInsidePolygonWithHoles(polyPoints, polyHoles, a) &&
+			isInsidePolygonWithHoles(polyPoints, polyHoles, b) &&
+			isLineSegmentInsidePolygonOrHoles(polyPoints, polyHoles, a, b) {
+			return true
+		}
+	}
+
+	return false
+}
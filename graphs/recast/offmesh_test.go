@@ -0,0 +1,72 @@
+package recast
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bolom009/astar"
+	"github.com/bolom009/geom"
+	"github.com/bolom009/pathfind/graphs"
+	"github.com/bolom009/pathfind/mesh"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecast_OffMeshLinks(t *testing.T) {
+	var (
+		left  = []geom.Vector2{{X: 0, Y: 0}, {X: 20, Y: 0}, {X: 20, Y: 20}, {X: 0, Y: 20}}
+		right = []geom.Vector2{{X: 40, Y: 0}, {X: 60, Y: 0}, {X: 60, Y: 20}, {X: 40, Y: 20}}
+		start = geom.Vector2{X: 5, Y: 10}
+		dest  = geom.Vector2{X: 55, Y: 10}
+		jump  = OffMeshLink{Start: geom.Vector2{X: 18, Y: 10}, End: geom.Vector2{X: 42, Y: 10}, Cost: 5, Meta: "jump"}
+	)
+
+	recastGraph := NewRecast([]*mesh.Polygon{
+		mesh.NewPolygon(left, nil, nil, 0),
+		mesh.NewPolygon(right, nil, nil, 0),
+	}, WithTriangleSearch(true))
+	if err := recastGraph.Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	findPath := func(start, dest geom.Vector2) []geom.Vector2 {
+		vis := recastGraph.AggregationGraph(start, dest, nil)
+		return astar.FindPath[geom.Vector2](vis, start, dest, recastGraph.HashIndex, recastGraph.Cost, recastGraph.Cost)
+	}
+
+	// polygons aren't connected without links
+	assert.Nil(t, findPath(start, dest))
+
+	ids := recastGraph.AddOffMeshLinks(jump)
+	assert.Len(t, ids, 1)
+
+	// triangle search can't go through links
+	_, ok := recastGraph.SearchPath(start, dest, nil)
+	assert.False(t, ok)
+
+	path := findPath(start, dest)
+	assert.Equal(t, []geom.Vector2{start, jump.Start, jump.End, dest}, path)
+	assert.Equal(t, float32(5), recastGraph.Cost(jump.Start, jump.End))
+	assert.Equal(t, []graphs.PathLink{{Segment: 1, ID: ids[0], Meta: "jump"}}, recastGraph.PathLinks(path, nil))
+
+	// link is directed
+	assert.Nil(t, findPath(dest, start))
+
+	// link survives rebuild by extra obstacles
+	obstacleIDs := recastGraph.AddObstacles(mesh.NewObstacle([]geom.Vector2{{X: 8, Y: 2}, {X: 12, Y: 2}, {X: 12, Y: 18}, {X: 8, Y: 18}}, 0, false))
+	path = findPath(start, dest)
+	assert.Len(t, recastGraph.PathLinks(path, nil), 1)
+	assert.Greater(t, len(path), 4)
+	recastGraph.RemoveObstacles(obstacleIDs...)
+
+	recastGraph.RemoveOffMeshLinks(ids...)
+	assert.Nil(t, findPath(start, dest))
+	assert.Equal(t, geom.Distance(jump.Start, jump.End), recastGraph.Cost(jump.Start, jump.End))
+
+	// bidirectional link without cost uses cost function
+	jump.Bidirectional, jump.Cost = true, 0
+	ids = recastGraph.AddOffMeshLinks(jump)
+	path = findPath(dest, start)
+	assert.Equal(t, []geom.Vector2{dest, jump.End, jump.Start, start}, path)
+	assert.Equal(t, []graphs.PathLink{{Segment: 1, ID: ids[0], Meta: "jump"}}, recastGraph.PathLinks(path, nil))
+	assert.Equal(t, geom.Distance(jump.Start, jump.End), recastGraph.Cost(jump.End, jump.Start))
+}
@@ -7,20 +7,22 @@ import (
 	"github.com/bolom009/pathfind/mesh"
 )
 
-func (r *Recast) getPolyOffsetsWithUnion(polygon *mesh.Polygon) goclipper2.PathsD {
+// getPolyOffsetsWithUnion offset polygon, its inner holes and obstacles by their offsets extended with agent radius
+// and union results to sub polygons with holes
+func (r *Recast) getPolyOffsetsWithUnion(polygon *mesh.Polygon, agentRadius float32) goclipper2.PathsD {
 	subject := make(goclipper2.PathsD, 0)
-	newPaths := inflatePathsD(goclipper2.PathsD{toPathD(polygon.Points())}, float64(-polygon.Offset()), goclipper2.Miter, goclipper2.Polygon)
+	newPaths := inflatePathsD(goclipper2.PathsD{toPathD(polygon.Points())}, float64(-polygon.Offset()-agentRadius), goclipper2.Miter, goclipper2.Polygon)
 	subject = append(subject, newPaths...)
 
 	for _, obstacle := range polygon.Obstacles() {
 		rPoints := goclipper2.ReversePath(obstacle.Points())
-		newPaths := inflatePathsD(goclipper2.PathsD{toPathD(rPoints)}, float64(obstacle.Offset()), goclipper2.Miter, goclipper2.Polygon)
+		newPaths := inflatePathsD(goclipper2.PathsD{toPathD(rPoints)}, float64(obstacle.Offset()+agentRadius), goclipper2.Miter, goclipper2.Polygon)
 
 		subject = append(subject, newPaths...)
 	}
 
 	for _, innerHole := range polygon.InnerHoles() {
-		newPaths := inflatePathsD(goclipper2.PathsD{toPathD(innerHole.Points())}, float64(innerHole.Offset()), goclipper2.Miter, goclipper2.Polygon)
+		newPaths := inflatePathsD(goclipper2.PathsD{toPathD(innerHole.Points())}, float64(innerHole.Offset()+agentRadius), goclipper2.Miter, goclipper2.Polygon)
 
 		subject = append(subject, newPaths...)
 	}
@@ -88,3 +90,23 @@ func unionPathsD(subject goclipper2.PathsD, fillRule goclipper2.FillRule) goclip
 	c.ExecuteWithScaleFunc(goclipper2.Union, fillRule, &solution, nil, scalePath64ToPathD)
 	return solution
 }
+
+func intersectPathsD(subject, clip goclipper2.PathsD, fillRule goclipper2.FillRule) goclipper2.PathsD {
+	solution := make(goclipper2.PathsD, 0)
+	c := goclipper2.NewClipperD(2)
+	c.AddPathsWithScaleFunc(subject, goclipper2.Subject, false, scalePathsDToPaths64)
+	c.AddPathsWithScaleFunc(clip, goclipper2.Clip, false, scalePathsDToPaths64)
+
+	c.ExecuteWithScaleFunc(goclipper2.Intersection, fillRule, &solution, nil, scalePath64ToPathD)
+	return solution
+}
+
+func differencePathsD(subject, clip goclipper2.PathsD, fillRule goclipper2.FillRule) goclipper2.PathsD {
+	solution := make(goclipper2.PathsD, 0)
+	c := goclipper2.NewClipperD(2)
+	c.AddPathsWithScaleFunc(subject, goclipper2.Subject, false, scalePathsDToPaths64)
+	c.AddPathsWithScaleFunc(clip, goclipper2.Clip, false, scalePathsDToPaths64)
+
+	c.ExecuteWithScaleFunc(goclipper2.Difference, fillRule, &solution, nil, scalePath64ToPathD)
+	return solution
+}
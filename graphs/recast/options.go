@@ -3,6 +3,8 @@ package recast
 import (
 	"github.com/bolom009/astar"
 	"github.com/bolom009/geom"
+	"github.com/bolom009/pathfind/graphs"
+	"github.com/bolom009/pathfind/mesh"
 )
 
 type option func(r *Recast)
@@ -18,3 +20,44 @@ func WithSearchOutOfArea(searchOutOfArea bool) option {
 		r.searchOutOfArea = searchOutOfArea
 	}
 }
+
+// WithTriangleSearch enable search path over adjacent triangles instead of visibility graph
+// Found triangle corridor is pulled taut by funnel algorithm, no graph copy is made per query
+func WithTriangleSearch(triangleSearch bool) option {
+	return func(r *Recast) {
+		r.triangleSearch = triangleSearch
+	}
+}
+
+// WithAgentRadii generate extra navigation layer for each agent radius
+// Agent radius is added to offsets of polygons and holes, layer is selected by graphs.NavOpts AgentRadius
+// Path isn't found for agent radius which is bigger than all agent radii
+func WithAgentRadii(agentRadii ...float32) option {
+	return func(r *Recast) {
+		r.agentRadii = agentRadii
+	}
+}
+
+// WithTileSize split walkable area to square tiles, so adding or removing extra obstacles
+// re-triangulates and relinks only tiles which are overlapped by obstacle
+func WithTileSize(tileSize float32) option {
+	return func(r *Recast) {
+		r.tileSize = tileSize
+	}
+}
+
+// WithProgress set callback to report progress of Generate
+func WithProgress(progressFunc graphs.ProgressFunc) option {
+	return func(r *Recast) {
+		r.progressFunc = progressFunc
+	}
+}
+
+// WithAreaCosts set cost multipliers of area types, cost of segment is its length multiplied by cost of area
+// Multipliers below mesh.MinAreaCost are clamped to it
+// Areas are added to polygons by mesh.Polygon AddAreas
+func WithAreaCosts(costs mesh.AreaCosts) option {
+	return func(r *Recast) {
+		r.areaCosts = costs
+	}
+}
@@ -2,13 +2,15 @@ package recast
 
 import (
 	"context"
+	"fmt"
 	"math"
+	"sync"
+	"sync/atomic"
 
 	"github.com/bolom009/astar"
-	"github.com/bolom009/delaunay"
 	"github.com/bolom009/geom"
-	goclipper2 "github.com/bolom009/go-clipper2"
 	"github.com/bolom009/pathfind/graphs"
+	"github.com/bolom009/pathfind/graphs/internal"
 	"github.com/bolom009/pathfind/mesh"
 )
 
@@ -17,140 +19,113 @@ type edge struct {
 	b geom.Vector2
 }
 
+// Recast is navigation graph based on triangulated polygons
+// Layers are immutable for queries: rebuild makes changed copies and swaps them atomically,
+// so queries don't wait for obstacle updates and updates are serialized by mutex
 type Recast struct {
 	polygons        []*mesh.Polygon
 	edges           []*edge
-	clippedPolygons []*mesh.Polygon
 	raycasts        []*Raycast
-	triangles       []Triangle
-	visibilityGraph graphs.Graph[geom.Vector2]
-	vertices        []geom.Vector2
+	layers          atomic.Pointer[[]*layer]
+	mu              sync.Mutex
+	agentRadii      []float32
+	tileSize        float32
 	costFunc        astar.CostFunc[geom.Vector2]
+	progressFunc    graphs.ProgressFunc
 	kdTree          *KDTree
 	searchOutOfArea bool
+	triangleSearch  bool
+	areaCosts       mesh.AreaCosts
+	areas           *mesh.Areas
 
 	// extra obstacles
-	obstaclePool                *obstaclePool
-	extraClippedPolygons        []*mesh.Polygon
-	extraEdges                  []*edge
-	extraObstacleId2ClippedPoly map[obstaclePolyPair]goclipper2.PathsD
+	obstaclePool *internal.ObstaclePool
+
+	// off-mesh links, registry is changed by writers and published as immutable snapshot
+	linkRegistry map[uint32]OffMeshLink
+	links        *offMeshLinks
+	nextLinkID   uint32
+
+	// changes is log of last updates which is checked by path caches
+	changes internal.Changes[BoundingBox]
 }
 
 func NewRecast(polygons []*mesh.Polygon, options ...option) *Recast {
 	r := &Recast{
-		polygons:                    polygons,
-		triangles:                   make([]Triangle, 0),
-		visibilityGraph:             make(graphs.Graph[geom.Vector2]),
-		raycasts:                    make([]*Raycast, len(polygons)),
-		extraClippedPolygons:        nil,
-		extraObstacleId2ClippedPoly: make(map[obstaclePolyPair]goclipper2.PathsD),
-		obstaclePool:                newObstaclePool(30),
-		costFunc:                    heuristicEvaluation,
+		polygons:     polygons,
+		raycasts:     make([]*Raycast, len(polygons)),
+		obstaclePool: internal.NewObstaclePool(30),
+		costFunc:     heuristicEvaluation,
+		linkRegistry: make(map[uint32]OffMeshLink),
+		links:        newOffMeshLinks(nil),
 	}
 
 	for _, option := range options {
 		option(r)
 	}
 
+	r.areas = mesh.NewAreas(polygonAreas(polygons), r.areaCosts)
+	radii := layerRadii(r.agentRadii)
+	layers := make([]*layer, len(radii))
+	for i, agentRadius := range radii {
+		layers[i] = newLayer(agentRadius)
+	}
+
+	r.layers.Store(&layers)
 	return r
 }
 
-func (r *Recast) Generate(_ context.Context) error {
-	r.prepareRaycasts()
-
-	oPolygons := make([]*mesh.Polygon, 0, len(r.polygons))
-	triLen := 0
-	k := 0
-	// offset each polygon and their innerHole + obstacles
-	// then union results from offsets for to get new sub polygons
-	for _, polygon := range r.polygons {
-		polyOffsets := r.getPolyOffsetsWithUnion(polygon)
-
-		rHoles := make([]*mesh.Hole, 0, len(polygon.Holes()))
-		subPolygons := make([][]geom.Vector2, 0)
-		for _, polyOffset := range polyOffsets {
-			cPoly := toPoint2(polyOffset)
-			if !goclipper2.IsPositiveD(polyOffset) {
-				rHoles = append(rHoles, mesh.NewObstacle(cPoly, 0, false))
-			} else {
-				subPolygons = append(subPolygons, cPoly)
-			}
-		}
-
-		for _, subPolygon := range subPolygons {
-			triLen += len(subPolygon)
-			subPolygonHoles := make([]*mesh.Hole, 0, len(rHoles))
-			for _, hole := range rHoles {
-				if isPolygonAFullyInsideB(hole.Points(), subPolygon, true) {
-					subPolygonHoles = append(subPolygonHoles, hole)
-					triLen += len(hole.Points())
-				}
-			}
-
-			oPolygons = append(oPolygons, mesh.NewPolygon(subPolygon, subPolygonHoles, nil, 0))
-			k++
-		}
+// Generate offsets, triangulates polygons and builds graphs of all layers
+// ctx is checked between polygons and regions (nil ctx is context.Background()), on cancel previously generated layers are kept
+func (r *Recast) Generate(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
 	}
 
-	// process recast data based on all polygons what we got during clipper2 process
-	triangles := make([]Triangle, 0, triLen)
-	for _, polygon := range oPolygons {
-		innerHoles := make([]*mesh.Hole, len(polygon.InnerHoles()))
-		for i, innerHole := range polygon.InnerHoles() {
-			innerHoles[i] = mesh.NewObstacle(innerHole.Points(), 0, innerHole.Viewable())
-		}
-
-		obstacles := make([]*mesh.Hole, len(polygon.Obstacles()))
-		for i, obstacle := range polygon.Obstacles() {
-			obstacles[i] = mesh.NewObstacle(obstacle.Points(), 0, obstacle.Viewable())
-		}
-
-		poly := mesh.NewPolygon(polygon.Points(), innerHoles, obstacles, 0)
-		r.clippedPolygons = append(r.clippedPolygons, poly)
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-		pp := make([]*delaunay.Point, 0)
-		for _, p := range poly.Points() {
-			pp = append(pp, delaunay.NewPoint(p.X, p.Y))
-		}
-
-		hh := make([][]*delaunay.Point, 0)
-		for _, hole := range poly.Holes() {
-			newHole := make([]*delaunay.Point, len(hole.Points()))
-			for i, p := range hole.Points() {
-				newHole[i] = delaunay.NewPoint(p.X, p.Y)
-			}
+	var (
+		radii  = layerRadii(r.agentRadii)
+		layers = make([]*layer, len(radii))
+		step   = 1 / float32(len(radii))
+	)
 
-			hh = append(hh, newHole)
+	for i, agentRadius := range radii {
+		layers[i] = newLayer(agentRadius)
+		if err := r.generateLayer(ctx, layers[i], float32(i)*step, step); err != nil {
+			return fmt.Errorf("generate layer %v: %w", agentRadius, err)
 		}
 
-		d := delaunay.NewSweepContext(pp, hh)
-
-		cTriangles := convertTriangles(d.Triangulate())
-		triangles = append(triangles, cTriangles...)
+		layers[i].prepareLinks(r.links)
 	}
 
-	r.extraClippedPolygons = make([]*mesh.Polygon, len(r.clippedPolygons))
-	copy(r.extraClippedPolygons, r.clippedPolygons)
-
-	r.prepareEdges(triLen)
-
-	r.triangles = triangles
-	// generate graph based on triangles
-	r.visibilityGraph = r.generateGraph()
-	r.vertices = make([]geom.Vector2, len(r.visibilityGraph))
-
-	i := 0
-	for v := range r.visibilityGraph {
-		r.vertices[i] = v
-		i++
-	}
+	r.prepareRaycasts()
+	r.layers.Store(&layers)
+	r.prepareEdges(len(layers[0].extraEdges))
+	r.changes.Record(internal.Change[BoundingBox]{Full: true})
+	r.reportProgress(1)
 
 	//r.kdTree = BuildKDTree(r.vertices, 0)
 	return nil
 }
 
-func (r *Recast) AggregationGraph(start, dest geom.Vector2, _ *graphs.NavOpts) graphs.Graph[geom.Vector2] {
-	for _, polygon := range r.extraClippedPolygons {
+// AggregationGraph add start and dest points to copy of visibility graph
+// Obstacles of navOpts (inflated by agent radius) and forbidden areas cut the copy, shared graph stays untouched
+func (r *Recast) AggregationGraph(start, dest geom.Vector2, navOpts *graphs.NavOpts) graphs.Graph[geom.Vector2] {
+	var (
+		l                = r.layer(navOpts)
+		obstaclePolygons = queryObstacles(navOpts)
+		filtered         = navOpts.HasAreaFilter()
+		// off-mesh link or path over cheap area could be cheaper than direct line
+		direct = !l.hasLinks() && !r.areas.Weighted() && !navOpts.HasAreaCosts() && !filtered
+	)
+
+	for _, polygon := range l.extraClippedPolygons {
+		if !direct {
+			break
+		}
+
 		polyPoints := polygon.Points()
 		polyHoles := polygon.Holes()
 		if !isInsidePolygonWithHoles(polyPoints, polyHoles, start) {
@@ -162,7 +137,8 @@ func (r *Recast) AggregationGraph(start, dest geom.Vector2, _ *graphs.NavOpts) g
 		}
 
 		// if start and dest in same visibility we can return start-dest graph
-		if isLineSegmentInsidePolygonOrHoles(polyPoints, polyHoles, start, dest) {
+		if isLineSegmentInsidePolygonOrHoles(polyPoints, polyHoles, start, dest) &&
+			!isSegmentBlocked(obstaclePolygons, start, dest) {
 			return graphs.Graph[geom.Vector2]{
 				start: []geom.Vector2{dest},
 				dest:  []geom.Vector2{start},
@@ -171,11 +147,16 @@ func (r *Recast) AggregationGraph(start, dest geom.Vector2, _ *graphs.NavOpts) g
 	}
 
 	var (
-		vis     = r.visibilityGraph.Copy()
-		startOk = false
-		destOk  = false
+		vis         = l.visibilityGraph.Copy()
+		startOk     = false
+		destOk      = false
+		extraPoints = []geom.Vector2{start, dest}
 	)
-	for _, triangle := range r.triangles {
+	for i, triangle := range l.triangles {
+		if filtered && !navOpts.AreaAllowed(l.triAreas[i]) {
+			continue
+		}
+
 		p0 := triangle[0]
 		p1 := triangle[1]
 		p2 := triangle[2]
@@ -196,12 +177,18 @@ func (r *Recast) AggregationGraph(start, dest geom.Vector2, _ *graphs.NavOpts) g
 		}
 	}
 
+	extraPoints = append(extraPoints, l.linkOffMesh(vis, navOpts, start, dest)...)
+	if filtered {
+		l.cutGraphWithAreas(vis, navOpts)
+	}
+
 	if r.searchOutOfArea {
 		if !startOk {
-			closestPoint, ok := r.closestPointOnPolygon(start)
+			closestPoint, ok := l.closestPointOnPolygon(start)
 			if ok {
+				extraPoints = append(extraPoints, closestPoint)
 				vis.LinkBoth(start, closestPoint)
-				visiblePoints := r.getVisiblePoints(closestPoint)
+				visiblePoints := l.getVisiblePoints(closestPoint)
 				for _, visiblePoint := range visiblePoints {
 					vis.LinkBoth(visiblePoint, closestPoint)
 				}
@@ -209,10 +196,11 @@ func (r *Recast) AggregationGraph(start, dest geom.Vector2, _ *graphs.NavOpts) g
 		}
 
 		if !destOk {
-			closestPoint, ok := r.closestPointOnPolygon(dest)
+			closestPoint, ok := l.closestPointOnPolygon(dest)
 			if ok {
+				extraPoints = append(extraPoints, closestPoint)
 				vis.LinkBoth(dest, closestPoint)
-				visiblePoints := r.getVisiblePoints(closestPoint)
+				visiblePoints := l.getVisiblePoints(closestPoint)
 				for _, visiblePoint := range visiblePoints {
 					vis.LinkBoth(visiblePoint, closestPoint)
 				}
@@ -220,163 +208,219 @@ func (r *Recast) AggregationGraph(start, dest geom.Vector2, _ *graphs.NavOpts) g
 		}
 	}
 
+	if len(obstaclePolygons) > 0 {
+		l.cutGraphWithObstacles(vis, obstaclePolygons, extraPoints...)
+	}
+
 	return vis
 }
 
-func (r *Recast) AddObstacles(obstacles ...*mesh.Hole) []uint32 {
-	if len(obstacles) == 0 {
-		return nil
+// SearchPath finds path over adjacent triangles if triangle search is enabled
+// Queries with obstacles or area filters, layers with off-mesh links and weighted areas are not handled, because they need to cut the graph per query.
+// This method makes Recast implement the graphs.PathSearcher interface.
+func (r *Recast) SearchPath(start, dest geom.Vector2, navOpts *graphs.NavOpts) ([]geom.Vector2, bool) {
+	if !r.triangleSearch {
+		return nil, false
 	}
 
-	ids := make([]uint32, len(obstacles))
-	for i, obstacle := range obstacles {
-		rPoints := goclipper2.ReversePath(obstacle.Points())
-		newPaths := inflatePathsD(goclipper2.PathsD{toPathD(rPoints)}, float64(obstacle.Offset()), goclipper2.Miter, goclipper2.Polygon)
+	if (navOpts != nil && len(navOpts.Obstacles) > 0) || navOpts.HasAreaFilter() || navOpts.HasAreaCosts() || r.areas.Weighted() {
+		return nil, false
+	}
 
-		ids[i] = r.obstaclePool.New(obstacle)
+	// corridor over adjacent triangles can't go through off-mesh links
+	l := r.layer(navOpts)
+	if l.hasLinks() {
+		return nil, false
+	}
 
-	loop:
-		for j, cPolygon := range r.clippedPolygons {
-			polyPoints := cPolygon.Points()
-			for _, p := range obstacle.Points() {
-				if pointInPolygon(p, polyPoints) {
-					key := obstaclePolyPair{oId: ids[i], pId: j}
-					r.extraObstacleId2ClippedPoly[key] = newPaths
+	return r.straightPath(l, start, dest, false), true
+}
 
-					break loop
-				}
-			}
-		}
+// Corridor return indexes of layer triangles which path goes through
+// This method makes Recast implement the graphs.PathDescriber interface.
+func (r *Recast) Corridor(path []geom.Vector2, navOpts *graphs.NavOpts) []int32 {
+	l := r.layer(navOpts)
+	if l.navMesh == nil {
+		return nil
 	}
 
-	r.rebuild()
-	return ids
+	return l.navMesh.pathCorridor(path)
 }
 
-func (r *Recast) RemoveObstacles(ids ...uint32) {
-	for _, id := range ids {
-		for opKey := range r.extraObstacleId2ClippedPoly {
-			if opKey.oId == id {
-				delete(r.extraObstacleId2ClippedPoly, opKey)
-			}
-		}
+// SnapPoint return closest point of layer area if point is out of area and search out of area is enabled
+// This method makes Recast implement the graphs.PathDescriber interface.
+func (r *Recast) SnapPoint(point geom.Vector2, navOpts *graphs.NavOpts) (geom.Vector2, bool) {
+	l := r.layer(navOpts)
+	if !r.searchOutOfArea || l.navMesh == nil {
+		return point, false
+	}
 
-		r.obstaclePool.Delete(id)
+	if _, ok := l.navMesh.findTriangle(point); ok {
+		return point, false
 	}
 
-	r.rebuild()
+	return l.closestPointOnPolygon(point)
 }
 
-func (r *Recast) rebuild() {
-	oPolygons := make([]*mesh.Polygon, 0, len(r.clippedPolygons))
-	for i, cPolygon := range r.clippedPolygons {
-		extraClippedObstacles := make([]goclipper2.PathsD, 0)
-		for opKey, pathsD := range r.extraObstacleId2ClippedPoly {
-			if opKey.pId == i {
-				extraClippedObstacles = append(extraClippedObstacles, pathsD)
-			}
-		}
+// StraightPath finds the shortest path from start to dest through triangle corridor
+// Corridor is triangles which A* path over visibility graph goes through, it's pulled taut by funnel algorithm,
+// so path goes through triangle corners only where it has to turn and it's never longer than A* path.
+// The function returns nil if no path exists
+func (r *Recast) StraightPath(start, dest geom.Vector2) []geom.Vector2 {
+	return r.straightPath(r.loadLayers()[0], start, dest, true)
+}
 
-		if len(extraClippedObstacles) == 0 {
-			oPolygons = append(oPolygons, cPolygon)
-			continue
-		}
+// straightPath pulls path through corridor of triangles crossed by A* path over visibility graph if vertexSearch
+// is true, otherwise corridor is searched over portals of adjacent triangles
+func (r *Recast) straightPath(l *layer, start, dest geom.Vector2, vertexSearch bool) []geom.Vector2 {
+	startTri, startPoint, ok := r.locateTriangle(l, start)
+	if !ok {
+		return nil
+	}
 
-		subject := goclipper2.PathsD{toPathD(cPolygon.Points())}
-		for _, hole := range cPolygon.Holes() {
-			subject = append(subject, toPathD(hole.Points()))
-		}
+	destTri, destPoint, ok := r.locateTriangle(l, dest)
+	if !ok {
+		return nil
+	}
 
-		for _, extraObstacle := range extraClippedObstacles {
-			subject = append(subject, extraObstacle...)
-		}
+	var path []geom.Vector2
+	if vertexSearch {
+		vis := r.AggregationGraph(startPoint, destPoint, &graphs.NavOpts{AgentRadius: l.agentRadius})
+		if vertexPath := astar.FindPath[geom.Vector2](vis, startPoint, destPoint, r.HashIndex, r.Cost, r.Estimate); vertexPath != nil {
+			if sleeve := l.navMesh.sleeve(vertexPath, startTri, destTri); sleeve != nil {
+				path = l.navMesh.funnel(startPoint, destPoint, sleeve)
+			}
 
-		// union clipped polygons with external subject
-		polyOffsets := unionPathsD(subject, goclipper2.Positive)
-
-		rHoles := make([]*mesh.Hole, 0, len(cPolygon.Holes()))
-		subPolygons := make([][]geom.Vector2, 0)
-		for _, polyOffset := range polyOffsets {
-			cPoly := toPoint2(polyOffset)
-			if !goclipper2.IsPositiveD(polyOffset) {
-				rHoles = append(rHoles, mesh.NewObstacle(cPoly, 0, false))
-			} else {
-				subPolygons = append(subPolygons, cPoly)
+			// sleeve could miss triangles where A* path goes along outline and go by other side of obstacle,
+			// A* path is kept then and if it goes through off-mesh links
+			if path == nil || pathLength(path) > pathLength(vertexPath) {
+				path = vertexPath
 			}
 		}
+	}
 
-		for _, subPolygon := range subPolygons {
-			subPolygonHoles := make([]*mesh.Hole, 0, len(rHoles))
-			for _, hole := range rHoles {
-				if isPolygonAFullyInsideB(hole.Points(), subPolygon, true) {
-					subPolygonHoles = append(subPolygonHoles, hole)
-				}
-			}
+	if path == nil {
+		path = l.navMesh.portalPath(startPoint, destPoint, startTri, destTri)
+	}
 
-			oPolygons = append(oPolygons, mesh.NewPolygon(subPolygon, subPolygonHoles, nil, 0))
-		}
+	if path == nil {
+		return nil
 	}
 
-	// TODO do we need to recalc raycast? raycast - check visibility to enemy through obstacle
-	r.extraClippedPolygons = r.extraClippedPolygons[:0]
+	if startPoint != start {
+		path = append([]geom.Vector2{start}, path...)
+	}
 
-	triangles := make([]Triangle, 0)
-	for _, polygon := range oPolygons {
-		innerHoles := make([]*mesh.Hole, len(polygon.InnerHoles()))
-		for i, innerHole := range polygon.InnerHoles() {
-			innerHoles[i] = mesh.NewObstacle(innerHole.Points(), 0, innerHole.Viewable())
-		}
+	return appendPoint(path, dest)
+}
 
-		oObstacles := make([]*mesh.Hole, len(polygon.Obstacles()))
-		for i, obstacle := range polygon.Obstacles() {
-			oObstacles[i] = mesh.NewObstacle(obstacle.Points(), 0, obstacle.Viewable())
-		}
+func pathLength(path []geom.Vector2) float32 {
+	var length float32
+	for i := 0; i < len(path)-1; i++ {
+		length += geom.Distance(path[i], path[i+1])
+	}
+
+	return length
+}
+
+// locateTriangle return triangle which contains point
+// if point is out of area and searchOutOfArea is enabled then closest triangle and point on it are returned
+func (r *Recast) locateTriangle(l *layer, point geom.Vector2) (int32, geom.Vector2, bool) {
+	if l.navMesh == nil {
+		return -1, point, false
+	}
+
+	if idx, ok := l.navMesh.findTriangle(point); ok {
+		return idx, point, true
+	}
+
+	if !r.searchOutOfArea {
+		return -1, point, false
+	}
+
+	return l.navMesh.closestTriangle(point)
+}
+
+func (r *Recast) AddObstacles(obstacles ...*mesh.Hole) []uint32 {
+	if len(obstacles) == 0 {
+		return nil
+	}
 
-		poly := mesh.NewPolygon(polygon.Points(), innerHoles, oObstacles, 0)
-		r.extraClippedPolygons = append(r.extraClippedPolygons, poly)
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-		pp := make([]*delaunay.Point, 0)
-		for _, p := range poly.Points() {
-			pp = append(pp, delaunay.NewPoint(p.X, p.Y))
+	layers := r.loadLayers()
+	ids := make([]uint32, len(obstacles))
+	for i, obstacle := range obstacles {
+		ids[i] = r.obstaclePool.New(obstacle)
+		for _, l := range layers {
+			l.addObstacle(ids[i], obstacle)
 		}
+	}
 
-		hh := make([][]*delaunay.Point, 0)
-		for _, hole := range poly.Holes() {
-			newHole := make([]*delaunay.Point, len(hole.Points()))
-			for i, p := range hole.Points() {
-				newHole[i] = delaunay.NewPoint(p.X, p.Y)
-			}
+	r.rebuild()
+	r.recordObstacles(obstacles)
+	return ids
+}
+
+func (r *Recast) RemoveObstacles(ids ...uint32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-			hh = append(hh, newHole)
+	layers := r.loadLayers()
+	for _, id := range ids {
+		for _, l := range layers {
+			l.removeObstacle(id)
 		}
 
-		d := delaunay.NewSweepContext(pp, hh)
+		r.obstaclePool.Delete(id)
+	}
+
+	r.rebuild()
+	r.changes.Record(internal.Change[BoundingBox]{Full: true})
+}
 
-		cTriangles := convertTriangles(d.Triangulate())
-		triangles = append(triangles, cTriangles...)
+// rebuild make copies of layers changed by extra obstacles and publish them
+// queries which are in progress keep reading previous layers
+func (r *Recast) rebuild() {
+	var (
+		layers = r.loadLayers()
+		next   = make([]*layer, len(layers))
+	)
+
+	for i, l := range layers {
+		if !l.isDirty() {
+			next[i] = l
+			continue
+		}
+
+		next[i] = l.clone()
+		r.rebuildLayer(next[i])
+		next[i].prepareLinks(r.links)
 	}
 
+	r.layers.Store(&next)
 	r.prepareEdges(len(r.edges))
+}
 
-	r.triangles = triangles
-	// generate graph based on triangles
-	r.visibilityGraph = r.generateGraph()
-	r.vertices = make([]geom.Vector2, len(r.visibilityGraph))
-
-	i := 0
-	for v := range r.visibilityGraph {
-		r.vertices[i] = v
-		i++
+func (r *Recast) reportProgress(progress float32) {
+	if r.progressFunc != nil {
+		r.progressFunc(progress)
 	}
 }
 
+// loadLayers return last published layers
+func (r *Recast) loadLayers() []*layer {
+	return *r.layers.Load()
+}
+
 func (r *Recast) GetClosestPoint(point geom.Vector2) (geom.Vector2, bool) {
 	// fastest way (~50ns) but return only vertex of polygon not closest point to polygon
 	//closestPoint, _ := r.kdTree.search(point)
 	//return closestPoint
 
 	// ~1000ns
-	closestPoint, ok := r.closestPointOnPolygon(point)
+	closestPoint, ok := r.loadLayers()[0].closestPointOnPolygon(point)
 	return closestPoint, ok
 }
 
@@ -390,8 +434,18 @@ func (r *Recast) IsRaycastHit(start, end geom.Vector2) bool {
 	return false
 }
 
-func (r *Recast) GetVisibility(_ *graphs.NavOpts) graphs.Graph[geom.Vector2] {
-	return r.visibilityGraph.Copy()
+func (r *Recast) GetVisibility(navOpts *graphs.NavOpts) graphs.Graph[geom.Vector2] {
+	l := r.layer(navOpts)
+	vis := l.visibilityGraph.Copy()
+	linkPoints := l.linkOffMesh(vis, navOpts)
+	if navOpts.HasAreaFilter() {
+		l.cutGraphWithAreas(vis, navOpts)
+	}
+	if obstaclePolygons := queryObstacles(navOpts); len(obstaclePolygons) > 0 {
+		l.cutGraphWithObstacles(vis, obstaclePolygons, linkPoints...)
+	}
+
+	return vis
 }
 
 func (r *Recast) ContainsPoint(point geom.Vector2) bool {
@@ -417,7 +471,39 @@ func (r *Recast) ContainsPoint(point geom.Vector2) bool {
 }
 
 func (r *Recast) Cost(a geom.Vector2, b geom.Vector2) float32 {
-	return r.costFunc(a, b)
+	if cost, ok := r.linkCost(a, b); ok {
+		return cost
+	}
+
+	return r.areas.SegmentCost(a, b, r.costFunc)
+}
+
+// QueryCosts return cost and estimate functions with area costs overridden by navOpts
+// This method makes Recast implement the graphs.QueryCoster interface.
+func (r *Recast) QueryCosts(navOpts *graphs.NavOpts) (cost, estimate func(a, b geom.Vector2) float32) {
+	if !navOpts.HasAreaCosts() {
+		return r.Cost, r.Estimate
+	}
+
+	areas := r.areas.WithCosts(navOpts.AreaCosts)
+	cost = func(a, b geom.Vector2) float32 {
+		if cost, ok := r.linkCost(a, b); ok {
+			return cost
+		}
+
+		return areas.SegmentCost(a, b, r.costFunc)
+	}
+	estimate = func(a, b geom.Vector2) float32 {
+		return r.costFunc(a, b) * areas.MinCost()
+	}
+
+	return cost, estimate
+}
+
+// Estimate return cost of direct line multiplied by the cheapest area cost, so it's never bigger than cost of path
+// This method makes Recast implement the graphs.Estimator interface.
+func (r *Recast) Estimate(a geom.Vector2, b geom.Vector2) float32 {
+	return r.costFunc(a, b) * r.areas.MinCost()
 }
 
 const (
@@ -441,26 +527,15 @@ func quantizeFloat(f float32) int64 {
 	return int64(f * scaleFactor)
 }
 
+// Triangles return triangles of base layer
 func (r *Recast) Triangles() []Triangle {
-	return r.triangles
+	return r.loadLayers()[0].triangles
 }
 
-func (r *Recast) generateGraph() graphs.Graph[geom.Vector2] {
-	vis := make(graphs.Graph[geom.Vector2], len(r.triangles))
-	for _, triangle := range r.triangles {
-		var (
-			p0 = triangle[0]
-			p1 = triangle[1]
-			p2 = triangle[2]
-		)
-
-		// link top vertices
-		vis.LinkBoth(p0, p1, 2)
-		vis.LinkBoth(p0, p2, 2)
-		vis.LinkBoth(p1, p2, 2)
-	}
-
-	return vis
+// LayerTriangles return triangles of layer which is used for agent radius, there are no triangles
+// if agent radius is bigger than all agent radii of graph
+func (r *Recast) LayerTriangles(agentRadius float32) []Triangle {
+	return r.layer(&graphs.NavOpts{AgentRadius: agentRadius}).triangles
 }
 
 func (r *Recast) prepareEdges(edgeLen int) {
@@ -476,14 +551,6 @@ func (r *Recast) prepareEdges(edgeLen int) {
 	for _, extraObstacle := range extraObstacles {
 		r.edges = append(r.edges, polyToEdges(extraObstacle.Points())...)
 	}
-
-	r.extraEdges = make([]*edge, 0, edgeLen)
-	for _, polygon := range r.extraClippedPolygons {
-		r.extraEdges = append(r.extraEdges, polyToEdges(polygon.Points())...)
-		for _, hole := range polygon.Holes() {
-			r.extraEdges = append(r.extraEdges, polyToEdges(hole.Points())...)
-		}
-	}
 }
 
 func polyToEdges(points []geom.Vector2) []*edge {
@@ -505,6 +572,16 @@ func (r *Recast) prepareRaycasts() {
 	}
 }
 
+// polygonAreas return cost areas of all polygons in order
+func polygonAreas(polygons []*mesh.Polygon) []*mesh.Hole {
+	areas := make([]*mesh.Hole, 0)
+	for _, polygon := range polygons {
+		areas = append(areas, polygon.Areas()...)
+	}
+
+	return areas
+}
+
 func isInsidePolygonWithHoles(polygon []geom.Vector2, holes []*mesh.Hole, point geom.Vector2) bool {
 	if !pointInPolygon(point, polygon) {
 		return false
@@ -640,100 +717,6 @@ func pointOnSegment(p, a, b geom.Vector2, eps float64) bool {
 	return math.Abs(float64(cross)) <= eps
 }
 
-func (r *Recast) getVisiblePoints(point geom.Vector2) []geom.Vector2 {
-	visiblePoints := make([]geom.Vector2, len(r.extraEdges))
-	count := 0
-
-	// TODO very fast check ~11146ns
-	for _, edge := range r.extraEdges {
-		if pointOnSegment(point, edge.a, edge.b, 1e-1) {
-			visiblePoints[count] = edge.a
-			count++
-			visiblePoints[count] = edge.b
-			count++
-		}
-	}
-
-	// TODO better but not so accurate :( ~99853ns
-	//for _, v := range r.vertices {
-	//	hasIntersection := false
-	//	for _, edge := range r.edges {
-	//		if lineSegmentIntersection(edge.a, edge.b, v, point) {
-	//			hasIntersection = true
-	//			break
-	//		}
-	//	}
-	//
-	//	if !hasIntersection {
-	//		visiblePoints[count] = v
-	//		count++
-	//	}
-	//}
-
-	// TODO more accurate but expensive :( ~190585ns
-	//for _, polygon := range r.polygons {
-	//	points := polygon.Points()
-	//	if !pointInPolygon(point, points) {
-	//		continue
-	//	}
-	//
-	//	holes := polygon.Holes()
-	//	for _, v := range r.vertices {
-	//		if isLineSegmentInsidePolygonOrHoles(points, holes, point, v) {
-	//			visiblePoints[count] = v
-	//			count++
-	//		}
-	//	}
-	//}
-
-	return visiblePoints[:count]
-}
-
-// closestPointOnPolygon finds the closest point on the polygon's boundary to the given point
-func (r *Recast) closestPointOnPolygon(startPoint geom.Vector2) (geom.Vector2, bool) {
-	minDist := float32(math.MaxFloat32)
-	closestFoundPoint := geom.Vector2{}
-	exist := false
-
-	// Check exterior ring
-	for _, polygon := range r.extraClippedPolygons {
-		clippedPolygon := polygon.Points()
-		for i := 0; i < len(clippedPolygon); i++ {
-			p1 := clippedPolygon[i]
-			p2 := clippedPolygon[(i+1)%len(clippedPolygon)] // Wrap around for last segment
-
-			closestOnSeg := closestPointOnSegment(startPoint, p1, p2)
-			dist := geom.Distance(startPoint, closestOnSeg)
-
-			if dist < minDist {
-				minDist = dist
-				closestFoundPoint = closestOnSeg
-				exist = true
-			}
-		}
-
-		// Check interior rings (holes)
-		for _, hole := range polygon.Holes() {
-			holePoints := hole.Points()
-			for i := 0; i < len(holePoints); i++ {
-				p1 := holePoints[i]
-				p2 := holePoints[(i+1)%len(holePoints)]
-
-				closestOnSeg := closestPointOnSegment(startPoint, p1, p2)
-				dist := geom.Distance(startPoint, closestOnSeg)
-
-				if dist < minDist {
-					minDist = dist
-					closestFoundPoint = closestOnSeg
-					exist = true
-				}
-			}
-		}
-	}
-
-	return closestFoundPoint, exist
-}
-
 func closestPointOnSegment(p, a, b geom.Vector2) geom.Vector2 {
 	ap := geom.Vector2{X: p.X - a.X, Y: p.Y - a.Y}
 	ab := geom.Vector2{X: b.X - a.X, Y: b.Y - a.Y}
@@ -0,0 +1,446 @@
+package recast
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/bolom009/geom"
+	goclipper2 "github.com/bolom009/go-clipper2"
+	"github.com/bolom009/pathfind/graphs/internal"
+	"github.com/bolom009/pathfind/mesh"
+)
+
+// Binary format of baked recast (little endian):
+//
+//	header:  magic "RCST", version uint16, tile size float32
+//	sources: polygons with inner holes, obstacles and cost areas (used for raycasts, ContainsPoint and costs)
+//	layers:  agent radius and regions, region contains polygon with holes, area type, triangles and their adjacency
+//
+// Version 1 has no cost areas, it's still loaded.
+//
+// Extra obstacles and off-mesh links are runtime data and aren't saved, regions are saved as they were generated.
+const (
+	bakeMagic   = "RCST"
+	bakeVersion = 2
+)
+
+var (
+	// ErrBadFormat is returned when data isn't baked recast or it's corrupted
+	ErrBadFormat = errors.New("bad recast data format")
+	// ErrUnsupportedVersion is returned when data is baked by unknown version of format
+	ErrUnsupportedVersion = errors.New("unsupported recast data version")
+)
+
+// MarshalBinary export generated state of recast (source polygons, clipped polygons, triangles and adjacency)
+// Recast could be loaded by UnmarshalBinary instead of Generate.
+// This method makes Recast implement the encoding.BinaryMarshaler interface.
+func (r *Recast) MarshalBinary() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w := &bakeWriter{buf: make([]byte, 0, 1024)}
+	w.buf = append(w.buf, bakeMagic...)
+	w.buf = binary.LittleEndian.AppendUint16(w.buf, bakeVersion)
+	w.float32(r.tileSize)
+
+	w.uint32(len(r.polygons))
+	for _, polygon := range r.polygons {
+		w.points(polygon.Points())
+		w.float32(polygon.Offset())
+		w.holes(polygon.InnerHoles())
+		w.holes(polygon.Obstacles())
+		w.areas(polygon.Areas())
+	}
+
+	layers := r.loadLayers()
+	w.uint32(len(layers))
+	for _, l := range layers {
+		w.float32(l.agentRadius)
+		w.uint32(len(l.regions))
+		for _, reg := range l.regions {
+			w.region(reg)
+		}
+	}
+
+	return w.buf, nil
+}
+
+// UnmarshalBinary load recast state exported by MarshalBinary, Generate isn't needed after it
+// Source polygons, tile size and agent radii are replaced by loaded ones, extra obstacles are dropped
+// and registered off-mesh links are kept.
+// This method makes Recast implement the encoding.BinaryUnmarshaler interface.
+func (r *Recast) UnmarshalBinary(data []byte) error {
+	rd := &bakeReader{data: data}
+	if string(rd.bytes(len(bakeMagic))) != bakeMagic {
+		return ErrBadFormat
+	}
+
+	if rd.version = rd.uint16(); rd.err == nil && (rd.version == 0 || rd.version > bakeVersion) {
+		return fmt.Errorf("%w: %d", ErrUnsupportedVersion, rd.version)
+	}
+
+	tileSize := rd.float32()
+
+	polygons := make([]*mesh.Polygon, rd.count(16))
+	for i := range polygons {
+		points := rd.outline()
+		offset := rd.float32()
+		innerHoles := rd.holes(true)
+		obstacles := rd.holes(false)
+		if rd.err != nil {
+			return rd.err
+		}
+
+		polygons[i] = mesh.NewPolygon(points, innerHoles, obstacles, offset)
+		if rd.version >= 2 {
+			polygons[i].AddAreas(rd.areas()...)
+		}
+	}
+
+	layers := make([]*layer, rd.count(8))
+	agentRadii := make([]float32, 0, len(layers))
+	for i := range layers {
+		layers[i] = newLayer(rd.float32())
+		layers[i].regions = make([]*region, rd.count(12))
+		for j := range layers[i].regions {
+			if layers[i].regions[j] = rd.region(); rd.err != nil {
+				return rd.err
+			}
+		}
+
+		agentRadii = append(agentRadii, layers[i].agentRadius)
+	}
+
+	if rd.err != nil {
+		return rd.err
+	}
+
+	if len(layers) == 0 || len(rd.data) != rd.pos {
+		return ErrBadFormat
+	}
+
+	for _, l := range layers {
+		l.prepareLayer()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, l := range layers {
+		l.prepareLinks(r.links)
+	}
+
+	r.polygons = polygons
+	r.tileSize = tileSize
+	r.agentRadii = agentRadii
+	r.areas = mesh.NewAreas(polygonAreas(polygons), r.areaCosts)
+	r.obstaclePool = internal.NewObstaclePool(30)
+	r.raycasts = make([]*Raycast, len(polygons))
+	r.prepareRaycasts()
+
+	r.layers.Store(&layers)
+	r.prepareEdges(len(layers[0].extraEdges))
+	r.changes.Record(internal.Change[BoundingBox]{Full: true})
+
+	return nil
+}
+
+type bakeWriter struct {
+	buf []byte
+}
+
+func (w *bakeWriter) uint32(v int) {
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, uint32(v))
+}
+
+func (w *bakeWriter) float32(v float32) {
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, math.Float32bits(v))
+}
+
+func (w *bakeWriter) point(p geom.Vector2) {
+	w.float32(p.X)
+	w.float32(p.Y)
+}
+
+func (w *bakeWriter) points(points []geom.Vector2) {
+	w.uint32(len(points))
+	for _, p := range points {
+		w.point(p)
+	}
+}
+
+func (w *bakeWriter) holes(holes []*mesh.Hole) {
+	w.uint32(len(holes))
+	for _, hole := range holes {
+		w.points(hole.Points())
+		w.float32(hole.Offset())
+		w.buf = append(w.buf, boolByte(hole.Viewable()))
+	}
+}
+
+func (w *bakeWriter) areas(areas []*mesh.Hole) {
+	w.uint32(len(areas))
+	for _, area := range areas {
+		w.points(area.Points())
+		w.buf = append(w.buf, byte(area.Area()))
+	}
+}
+
+// region write region as it was generated, triangles of base polygon are restored if extra obstacles cut it
+func (w *bakeWriter) region(reg *region) {
+	w.points(reg.polygon.Points())
+	w.holes(reg.polygon.Holes())
+	w.buf = append(w.buf, byte(reg.area))
+
+	triangles, navMesh := reg.triangles, reg.navMesh
+	if len(reg.obstacles) > 0 {
+		_, triangles = triangulate([]*mesh.Polygon{reg.polygon})
+		navMesh = buildNavMesh(triangles)
+	}
+
+	w.uint32(len(triangles))
+	for i, triangle := range triangles {
+		for _, p := range triangle {
+			w.point(p)
+		}
+
+		// neighbour and side of triangle which is shared with it
+		w.buf = append(w.buf, byte(len(navMesh.neighbours[i])))
+		for j, neighbour := range navMesh.neighbours[i] {
+			w.uint32(int(neighbour))
+			w.buf = append(w.buf, byte(portalSide(triangle, navMesh.portals[i][j])))
+		}
+	}
+
+	w.uint32(len(navMesh.open))
+	for _, side := range navMesh.open {
+		w.uint32(int(side.tri))
+		w.buf = append(w.buf, byte(side.side))
+	}
+}
+
+// portalSide return index of triangle side which is portal
+func portalSide(triangle Triangle, p portal) int {
+	for j := 0; j < 3; j++ {
+		if left, right := sidePortal(triangle, j); left == p.left && right == p.right {
+			return j
+		}
+	}
+
+	return 0
+}
+
+func boolByte(v bool) byte {
+	if v {
+		return 1
+	}
+
+	return 0
+}
+
+// bakeReader read values of baked data, after first error all values are zero
+type bakeReader struct {
+	data    []byte
+	pos     int
+	version uint16
+	err     error
+}
+
+func (rd *bakeReader) bytes(n int) []byte {
+	if rd.err != nil {
+		return nil
+	}
+
+	if n < 0 || len(rd.data)-rd.pos < n {
+		rd.err = ErrBadFormat
+		return nil
+	}
+
+	b := rd.data[rd.pos : rd.pos+n]
+	rd.pos += n
+	return b
+}
+
+func (rd *bakeReader) byte() byte {
+	if b := rd.bytes(1); b != nil {
+		return b[0]
+	}
+
+	return 0
+}
+
+func (rd *bakeReader) uint16() uint16 {
+	if b := rd.bytes(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+
+	return 0
+}
+
+func (rd *bakeReader) uint32() uint32 {
+	if b := rd.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+
+	return 0
+}
+
+func (rd *bakeReader) float32() float32 {
+	return math.Float32frombits(rd.uint32())
+}
+
+// count read length of list, itemSize is minimal size of item to check that data has enough bytes for the list
+func (rd *bakeReader) count(itemSize int) int {
+	n := int(rd.uint32())
+	if rd.err == nil && n > (len(rd.data)-rd.pos)/itemSize {
+		rd.err = ErrBadFormat
+	}
+
+	if rd.err != nil {
+		return 0
+	}
+
+	return n
+}
+
+func (rd *bakeReader) point() geom.Vector2 {
+	return geom.Vector2{X: rd.float32(), Y: rd.float32()}
+}
+
+func (rd *bakeReader) points() []geom.Vector2 {
+	points := make([]geom.Vector2, rd.count(8))
+	for i := range points {
+		points[i] = rd.point()
+	}
+
+	return points
+}
+
+// outline read points of polygon, hole or area, outline with less than 3 points is corrupted
+func (rd *bakeReader) outline() []geom.Vector2 {
+	points := rd.points()
+	if rd.err == nil && len(points) < 3 {
+		rd.err = ErrBadFormat
+	}
+
+	return points
+}
+
+func (rd *bakeReader) holes(inner bool) []*mesh.Hole {
+	holes := make([]*mesh.Hole, rd.count(9))
+	for i := range holes {
+		points := rd.outline()
+		offset := rd.float32()
+		viewable := rd.byte() == 1
+		if rd.err != nil {
+			return nil
+		}
+
+		if inner {
+			holes[i] = mesh.NewInnerHole(points, offset)
+		} else {
+			holes[i] = mesh.NewObstacle(points, offset, viewable)
+		}
+	}
+
+	return holes
+}
+
+func (rd *bakeReader) areas() []*mesh.Hole {
+	areas := make([]*mesh.Hole, rd.count(5))
+	for i := range areas {
+		points := rd.outline()
+		area := mesh.AreaType(rd.byte())
+		if rd.err != nil {
+			return nil
+		}
+
+		areas[i] = mesh.NewArea(points, area)
+	}
+
+	return areas
+}
+
+// region read region, it returns nil after first error
+func (rd *bakeReader) region() *region {
+	var (
+		points = rd.outline()
+		holes  = rd.holes(false)
+		area   = mesh.AreaDefault
+		m      = &navMesh{}
+	)
+
+	if rd.version >= 2 {
+		area = mesh.AreaType(rd.byte())
+	}
+
+	if rd.err != nil {
+		return nil
+	}
+
+	polygon := mesh.NewPolygon(points, holes, nil, 0)
+
+	triLen := rd.count(25)
+	m.triangles = make([]Triangle, triLen)
+	m.neighbours = make([][]int32, triLen)
+	m.portals = make([][]portal, triLen)
+	for i := range m.triangles {
+		triangle := Triangle{rd.point(), rd.point(), rd.point()}
+		m.triangles[i] = triangle
+
+		n := int(rd.byte())
+		m.neighbours[i] = make([]int32, 0, n)
+		m.portals[i] = make([]portal, 0, n)
+		for range n {
+			neighbour, side := rd.index(triLen), int(rd.byte())
+			left, right := sidePortal(triangle, side%3)
+			m.neighbours[i] = append(m.neighbours[i], neighbour)
+			m.portals[i] = append(m.portals[i], portal{left: left, right: right})
+		}
+	}
+
+	if rd.err != nil {
+		return nil
+	}
+
+	m.open = make([]openSide, rd.count(5))
+	for i := range m.open {
+		tri, side := rd.index(triLen), int(rd.byte())%3
+		if rd.err != nil {
+			break
+		}
+
+		triangle := m.triangles[tri]
+		m.open[i] = openSide{key: newTriEdge(triangle[side], triangle[(side+1)%3]), tri: tri, side: side}
+	}
+
+	if rd.err != nil {
+		return nil
+	}
+
+	return &region{
+		polygon:   polygon,
+		bounds:    getBoundingBox(points),
+		obstacles: make(map[uint32]goclipper2.PathsD),
+		polygons:  []*mesh.Polygon{polygon},
+		triangles: m.triangles,
+		navMesh:   m,
+		area:      area,
+	}
+}
+
+// index read triangle index and check it's in range
+func (rd *bakeReader) index(n int) int32 {
+	idx := rd.uint32()
+	if rd.err == nil && int(idx) >= n {
+		rd.err = ErrBadFormat
+	}
+
+	if rd.err != nil {
+		return 0
+	}
+
+	return int32(idx)
+}
@@ -0,0 +1,148 @@
+package recast
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/bolom009/astar"
+	"github.com/bolom009/geom"
+	"github.com/bolom009/pathfind/graphs"
+	"github.com/bolom009/pathfind/mesh"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecast_MarshalBinary(t *testing.T) {
+	rPolygons, err := loadLargeLocation()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recastGraph := NewRecast(rPolygons, WithTileSize(64), WithAgentRadii(2), WithSearchOutOfArea(true))
+	if err = recastGraph.Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// extra obstacles aren't baked
+	recastGraph.AddObstacles(generateExtraObstacles(10)...)
+
+	data, err := recastGraph.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewRecast(nil, WithSearchOutOfArea(true))
+	if err = loaded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	generated := NewRecast(rPolygons, WithTileSize(64), WithAgentRadii(2), WithSearchOutOfArea(true))
+	if err = generated.Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, loaded.loadLayers(), 2)
+	for i, l := range generated.loadLayers() {
+		loadedLayer := loaded.loadLayers()[i]
+		assert.Equal(t, l.agentRadius, loadedLayer.agentRadius)
+		assert.Equal(t, l.triangles, loadedLayer.triangles)
+		assert.Equal(t, l.navMesh.neighbours, loadedLayer.navMesh.neighbours)
+		assert.Equal(t, l.navMesh.portals, loadedLayer.navMesh.portals)
+		assert.Equal(t, len(l.visibilityGraph), len(loadedLayer.visibilityGraph))
+	}
+
+	var (
+		start = geom.Vector2{X: 202, Y: -268}
+		dest  = geom.Vector2{X: -4, Y: 84}
+	)
+
+	for _, agentRadius := range []float32{0, 2} {
+		navOpts := &graphs.NavOpts{AgentRadius: agentRadius}
+		vis := loaded.AggregationGraph(start, dest, navOpts)
+		path := astar.FindPath[geom.Vector2](vis, start, dest, loaded.HashIndex, loaded.Cost, loaded.Cost)
+		assert.NotEmpty(t, path)
+
+		vis = generated.AggregationGraph(start, dest, navOpts)
+		assert.Equal(t, astar.FindPath[geom.Vector2](vis, start, dest, generated.HashIndex, generated.Cost, generated.Cost), path)
+	}
+
+	assert.Equal(t, generated.ContainsPoint(start), loaded.ContainsPoint(start))
+	assert.Equal(t, generated.IsRaycastHit(start, dest), loaded.IsRaycastHit(start, dest))
+}
+
+func TestRecast_UnmarshalBinaryErrors(t *testing.T) {
+	recastGraph := NewRecast(nil)
+	if err := recastGraph.Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := recastGraph.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.ErrorIs(t, NewRecast(nil).UnmarshalBinary(nil), ErrBadFormat)
+	assert.ErrorIs(t, NewRecast(nil).UnmarshalBinary([]byte("NAVMESH")), ErrBadFormat)
+	assert.ErrorIs(t, NewRecast(nil).UnmarshalBinary(data[:len(data)-1]), ErrBadFormat)
+	assert.ErrorIs(t, NewRecast(nil).UnmarshalBinary(append(data, 0)), ErrBadFormat)
+
+	newVersion := append([]byte{}, data...)
+	binary.LittleEndian.PutUint16(newVersion[len(bakeMagic):], bakeVersion+1)
+	assert.ErrorIs(t, NewRecast(nil).UnmarshalBinary(newVersion), ErrUnsupportedVersion)
+
+	// huge list length
+	hugeList := append([]byte{}, data...)
+	binary.LittleEndian.PutUint32(hugeList[len(bakeMagic)+6:], 1<<30)
+	assert.ErrorIs(t, NewRecast(nil).UnmarshalBinary(hugeList), ErrBadFormat)
+
+	assert.NoError(t, NewRecast(nil).UnmarshalBinary(data))
+}
+
+func TestRecast_UnmarshalBinaryTruncated(t *testing.T) {
+	room := []geom.Vector2{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}}
+	hole := []geom.Vector2{{X: 4, Y: 4}, {X: 4, Y: 6}, {X: 6, Y: 6}, {X: 6, Y: 4}}
+
+	recastGraph := NewRecast([]*mesh.Polygon{mesh.NewPolygon(room, []*mesh.Hole{mesh.NewInnerHole(hole, 0)}, nil, 0)})
+	if err := recastGraph.Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := recastGraph.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for n := range len(data) {
+		assert.NotPanics(t, func() {
+			assert.ErrorIs(t, NewRecast(nil).UnmarshalBinary(data[:n]), ErrBadFormat, n)
+		})
+	}
+}
+
+// BenchmarkRecast_Generate-16           	    1600	   1485436 ns/op	  552584 B/op	    6805 allocs/op
+// BenchmarkRecast_UnmarshalBinary-16    	    6505	    365086 ns/op	  234024 B/op	    1844 allocs/op
+func BenchmarkRecast_UnmarshalBinary(b *testing.B) {
+	rPolygons, err := loadLargeLocation()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	recastGraph := NewRecast(rPolygons)
+	if err = recastGraph.Generate(context.Background()); err != nil {
+		b.Fatal(err)
+	}
+
+	data, err := recastGraph.MarshalBinary()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for b.Loop() {
+		if err = NewRecast(nil).UnmarshalBinary(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package recast
+
+import (
+	"slices"
+
+	"github.com/bolom009/geom"
+	"github.com/bolom009/pathfind/graphs"
+	"github.com/bolom009/pathfind/mesh"
+)
+
+// sightEps is distance to outline of clipped polygon which is treated as walkable, path could go along walls
+const sightEps = 1e-3
+
+// LineOfSight return check of straight moves over layer of query, segment is walkable if it goes only through
+// clipped polygons of allowed areas (holes, extra obstacles and agent radius are cut from them)
+// and doesn't cross obstacles of navOpts inflated by agent radius.
+// This method makes Recast implement the graphs.SightChecker interface.
+func (r *Recast) LineOfSight(navOpts *graphs.NavOpts) func(a, b geom.Vector2) bool {
+	var (
+		l                = r.layer(navOpts)
+		obstaclePolygons = queryObstacles(navOpts)
+		polygons         = make([]*mesh.Polygon, 0, len(l.extraClippedPolygons))
+		bounds           = make([]BoundingBox, 0, len(l.extraClippedPolygons))
+	)
+
+	for i, polygon := range l.extraClippedPolygons {
+		if navOpts.AreaAllowed(l.clippedAreas[i]) {
+			polygons = append(polygons, polygon)
+			bounds = append(bounds, getBoundingBox(polygon.Points()))
+		}
+	}
+
+	return func(a, b geom.Vector2) bool {
+		return isSegmentWalkable(polygons, bounds, a, b) && !isSegmentBlocked(obstaclePolygons, a, b)
+	}
+}
+
+// isSegmentWalkable checks if segment goes only through polygons with holes or along their outlines
+// Segment is split where it crosses outlines, so each part is whole inside or whole out of polygons
+// and it's checked by its middle point
+func isSegmentWalkable(polygons []*mesh.Polygon, bounds []BoundingBox, a, b geom.Vector2) bool {
+	var (
+		box    = BoundingBox{MinX: min(a.X, b.X), MinY: min(a.Y, b.Y), MaxX: max(a.X, b.X), MaxY: max(a.Y, b.Y)}
+		near   = make([]*mesh.Polygon, 0, 4)
+		params = []float32{0, 1}
+	)
+
+	for i, polygon := range polygons {
+		if !boundingBoxesOverlap(box, bounds[i]) {
+			continue
+		}
+
+		near = append(near, polygon)
+		params = appendOutlineParams(params, polygon.Points(), a, b)
+		for _, hole := range polygon.Holes() {
+			params = appendOutlineParams(params, hole.Points(), a, b)
+		}
+	}
+
+	slices.Sort(params)
+	for i := 0; i < len(params)-1; i++ {
+		if params[i+1]-params[i] < 1e-6 {
+			continue
+		}
+
+		middle := a.Lerp(b, (params[i]+params[i+1])/2)
+		if !slices.ContainsFunc(near, func(polygon *mesh.Polygon) bool {
+			return isInsidePolygonWithHoles(polygon.Points(), polygon.Holes(), middle) || isPointOnOutline(polygon, middle)
+		}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// appendOutlineParams append parameters of segment points where it crosses or touches edges of closed outline,
+// edges which lie on segment add both their ends
+func appendOutlineParams(params []float32, outline []geom.Vector2, a, b geom.Vector2) []float32 {
+	var (
+		dx, dy = float64(b.X - a.X), float64(b.Y - a.Y)
+		lenSq  = dx*dx + dy*dy
+	)
+
+	if lenSq == 0 {
+		return params
+	}
+
+	for i := range outline {
+		var (
+			p, q   = outline[i], outline[(i+1)%len(outline)]
+			ex, ey = float64(q.X - p.X), float64(q.Y - p.Y)
+			px, py = float64(p.X - a.X), float64(p.Y - a.Y)
+			denom  = dx*ey - dy*ex
+		)
+
+		if denom != 0 {
+			t, u := (px*ey-py*ex)/denom, (px*dy-py*dx)/denom
+			if t > 0 && t < 1 && u >= 0 && u <= 1 {
+				params = append(params, float32(t))
+			}
+
+			continue
+		}
+
+		// parallel edge splits segment only if it lies on segment line
+		if px*dy-py*dx != 0 {
+			continue
+		}
+
+		for _, t := range [2]float64{(px*dx + py*dy) / lenSq, ((px+ex)*dx + (py+ey)*dy) / lenSq} {
+			if t > 0 && t < 1 {
+				params = append(params, float32(t))
+			}
+		}
+	}
+
+	return params
+}
+
+// isPointOnOutline checks if point is closer than sightEps to outline of polygon or its holes
+func isPointOnOutline(polygon *mesh.Polygon, point geom.Vector2) bool {
+	outlines := [][]geom.Vector2{polygon.Points()}
+	for _, hole := range polygon.Holes() {
+		outlines = append(outlines, hole.Points())
+	}
+
+	for _, outline := range outlines {
+		for i := range outline {
+			if geom.Distance(point, closestPointOnSegment(point, outline[i], outline[(i+1)%len(outline)])) < sightEps {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,224 @@
+package mesh
+
+import (
+	"math"
+	"slices"
+
+	"github.com/bolom009/geom"
+)
+
+// AreaType is tag of walkable surface (road, mud, water), AreaDefault is surface out of cost areas
+type AreaType uint8
+
+const AreaDefault AreaType = 0
+
+// MinAreaCost is the smallest cost multiplier, smaller multipliers (zero or negative) are clamped to it
+const MinAreaCost float32 = 0.01
+
+// AreaCosts contains cost multipliers of area types, area types without multiplier cost 1
+// Path stays the shortest with multipliers below 1, because estimation of path cost is multiplied by the
+// smallest multiplier, but weaker estimation makes search expand more nodes
+type AreaCosts map[AreaType]float32
+
+// Cost return multiplier of area type, it isn't smaller than MinAreaCost
+func (c AreaCosts) Cost(area AreaType) float32 {
+	if cost, ok := c[area]; ok {
+		return max(cost, MinAreaCost)
+	}
+
+	return 1
+}
+
+// Areas measure cost of segments which go through cost areas
+// segment is split by outlines of areas and each part cost is multiplied by cost of its area,
+// later areas overlap earlier ones
+type Areas struct {
+	areas    []*Hole
+	boxes    []areaBox
+	costs    AreaCosts
+	minCost  float32
+	weighted bool
+}
+
+type areaBox struct {
+	minX, minY, maxX, maxY float32
+}
+
+func NewAreas(areas []*Hole, costs AreaCosts) *Areas {
+	a := &Areas{
+		areas: areas,
+		boxes: make([]areaBox, len(areas)),
+	}
+
+	for i, area := range areas {
+		a.boxes[i] = newAreaBox(area.Points())
+	}
+
+	a.setCosts(costs)
+	return a
+}
+
+func (a *Areas) setCosts(costs AreaCosts) {
+	a.costs = costs
+	a.minCost = costs.Cost(AreaDefault)
+	a.weighted = a.minCost != 1
+	for _, area := range a.areas {
+		cost := costs.Cost(area.Area())
+		a.minCost = min(a.minCost, cost)
+		a.weighted = a.weighted || cost != 1
+	}
+}
+
+// WithCosts return areas with costs overridden by costs of query
+func (a *Areas) WithCosts(overrides AreaCosts) *Areas {
+	if len(overrides) == 0 {
+		return a
+	}
+
+	costs := make(AreaCosts, len(a.costs)+len(overrides))
+	for area, cost := range a.costs {
+		costs[area] = cost
+	}
+	for area, cost := range overrides {
+		costs[area] = cost
+	}
+
+	c := *a
+	c.setCosts(costs)
+	return &c
+}
+
+// Areas return list of cost areas
+func (a *Areas) Areas() []*Hole {
+	return a.areas
+}
+
+// Weighted checks if any area changes cost of segments
+func (a *Areas) Weighted() bool {
+	return a.weighted
+}
+
+// MinCost return the smallest multiplier of areas, estimation of path cost multiplied by it is never bigger than cost
+func (a *Areas) MinCost() float32 {
+	return a.minCost
+}
+
+// AreaAt return area type of point, AreaDefault is returned if point is out of areas
+func (a *Areas) AreaAt(point geom.Vector2) AreaType {
+	for i := len(a.areas) - 1; i >= 0; i-- {
+		if a.boxes[i].contains(point) && pointInPolygon(point, a.areas[i].Points()) {
+			return a.areas[i].Area()
+		}
+	}
+
+	return AreaDefault
+}
+
+// SegmentCost return cost of segment a-b, costFunc measures parts of segment which lie in one area
+func (a *Areas) SegmentCost(p0, p1 geom.Vector2, costFunc func(a, b geom.Vector2) float32) float32 {
+	if !a.weighted {
+		return costFunc(p0, p1)
+	}
+
+	var (
+		box     = newAreaBox([]geom.Vector2{p0, p1})
+		params  = []float32{0, 1}
+		overlap = false
+	)
+
+	for i, area := range a.areas {
+		if !a.boxes[i].overlaps(box) {
+			continue
+		}
+
+		overlap = true
+		points := area.Points()
+		for j := range points {
+			if t, ok := segmentIntersection(p0, p1, points[j], points[(j+1)%len(points)]); ok {
+				params = append(params, t)
+			}
+		}
+	}
+
+	if !overlap {
+		return costFunc(p0, p1) * a.costs.Cost(AreaDefault)
+	}
+
+	slices.Sort(params)
+
+	cost := float32(0)
+	for i := 0; i < len(params)-1; i++ {
+		if params[i+1] <= params[i] {
+			continue
+		}
+
+		var (
+			from   = p0.Lerp(p1, params[i])
+			to     = p0.Lerp(p1, params[i+1])
+			middle = p0.Lerp(p1, (params[i]+params[i+1])/2)
+		)
+
+		cost += costFunc(from, to) * a.costs.Cost(a.AreaAt(middle))
+	}
+
+	return cost
+}
+
+func newAreaBox(points []geom.Vector2) areaBox {
+	box := areaBox{minX: math.MaxFloat32, minY: math.MaxFloat32, maxX: -math.MaxFloat32, maxY: -math.MaxFloat32}
+	for _, p := range points {
+		box.minX, box.maxX = min(box.minX, p.X), max(box.maxX, p.X)
+		box.minY, box.maxY = min(box.minY, p.Y), max(box.maxY, p.Y)
+	}
+
+	return box
+}
+
+func (b areaBox) contains(p geom.Vector2) bool {
+	return p.X >= b.minX && p.X <= b.maxX && p.Y >= b.minY && p.Y <= b.maxY
+}
+
+func (b areaBox) overlaps(o areaBox) bool {
+	return b.minX <= o.maxX && b.maxX >= o.minX && b.minY <= o.maxY && b.maxY >= o.minY
+}
+
+// segmentIntersection return parameter of intersection point on segment p0-p1, parallel segments don't intersect
+func segmentIntersection(p0, p1, q0, q1 geom.Vector2) (float32, bool) {
+	var (
+		r     = p1.Sub(p0)
+		s     = q1.Sub(q0)
+		denom = r.X*s.Y - r.Y*s.X
+	)
+
+	if denom == 0 {
+		return 0, false
+	}
+
+	qp := q0.Sub(p0)
+	t := (qp.X*s.Y - qp.Y*s.X) / denom
+	u := (qp.X*r.Y - qp.Y*r.X) / denom
+	if t <= 0 || t >= 1 || u < 0 || u > 1 {
+		return 0, false
+	}
+
+	return t, true
+}
+
+// pointInPolygon checks if a point p is inside a polygon using the ray casting method.
+func pointInPolygon(p geom.Vector2, poly []geom.Vector2) bool {
+	inside := false
+	n := len(poly)
+	for i := 0; i < n; i++ {
+		p1 := poly[i]
+		p2 := poly[(i+1)%n]
+
+		condY := (p1.Y <= p.Y && p2.Y > p.Y) || (p2.Y <= p.Y && p1.Y > p.Y)
+		if condY {
+			xIntersect := p1.X + (p.Y-p1.Y)*(p2.X-p1.X)/(p2.Y-p1.Y)
+			if xIntersect > p.X {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
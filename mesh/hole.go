@@ -9,6 +9,7 @@ type HoleType uint8
 const (
 	InnerHole HoleType = iota + 1
 	Obstacle
+	CostArea
 )
 
 type Hole struct {
@@ -16,6 +17,7 @@ type Hole struct {
 	hType    HoleType
 	offset   float32
 	viewable bool
+	area     AreaType
 }
 
 func NewInnerHole(points []geom.Vector2, offset float32) *Hole {
@@ -36,6 +38,16 @@ func NewObstacle(points []geom.Vector2, offset float32, viewable bool) *Hole {
 	}
 }
 
+// NewArea create walkable area of polygon with area type (road, mud, water)
+// areas aren't holes, they are carved into graph and change cost of segments which go through them
+func NewArea(points []geom.Vector2, area AreaType) *Hole {
+	return &Hole{
+		points: points,
+		area:   area,
+		hType:  CostArea,
+	}
+}
+
 func (h *Hole) Points() []geom.Vector2 {
 	return h.points
 }
@@ -51,3 +63,8 @@ func (h *Hole) Viewable() bool {
 func (h *Hole) Type() HoleType {
 	return h.hType
 }
+
+// Area return area type of cost area, holes and obstacles have AreaDefault
+func (h *Hole) Area() AreaType {
+	return h.area
+}
@@ -14,6 +14,8 @@ type Polygon struct {
 	holes []*Hole
 	// use for clipper2 offset process
 	offset float32
+	// areas represent walkable parts of polygon with own area type (road, mud, water)
+	areas []*Hole
 }
 
 func NewPolygon(points []geom.Vector2, innerHoles []*Hole, obstacles []*Hole, offset float32) *Polygon {
@@ -45,3 +47,13 @@ func (p *Polygon) Obstacles() []*Hole {
 func (p *Polygon) Offset() float32 {
 	return p.offset
 }
+
+// AddAreas add cost areas to polygon, later areas overlap earlier ones
+func (p *Polygon) AddAreas(areas ...*Hole) *Polygon {
+	p.areas = append(p.areas, areas...)
+	return p
+}
+
+func (p *Polygon) Areas() []*Hole {
+	return p.areas
+}
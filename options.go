@@ -2,9 +2,36 @@ package pathfind
 
 import (
 	"github.com/bolom009/pathfind/graphs"
+	"github.com/bolom009/pathfind/mesh"
 	"github.com/bolom009/pathfind/obstacles"
 )
 
+// Option represent setter of options to pathfinder
+type Option func(*pathfinderOptions)
+
+type pathfinderOptions struct {
+	workers   int
+	cacheSize int
+}
+
+// WithWorkers set count of workers of PathBatch, by default it's GOMAXPROCS
+func WithWorkers(workers int) Option {
+	return func(o *pathfinderOptions) {
+		o.workers = workers
+	}
+}
+
+// WithPathCache enable cache of found paths with limit of size paths, the least recently used paths are evicted
+// Paths are keyed by exact start and dest (graph HashIndex), agent radius and JPS option, so only repeated
+// queries of the same points hit the cache. Paths of queries with obstacles or area options aren't cached.
+// Cached path is dropped when graph obstacles are changed in its area (graph must implement graphs.ChangeTracker,
+// otherwise graph is treated as static)
+func WithPathCache(size int) Option {
+	return func(o *pathfinderOptions) {
+		o.cacheSize = size
+	}
+}
+
 // PathOption represent setter of options to navigation
 type PathOption func(*graphs.NavOpts)
 
@@ -21,3 +48,49 @@ func WithAgentRadius(agentRadius float32) PathOption {
 		o.AgentRadius = agentRadius
 	}
 }
+
+// WithIncludeAreas allow only listed area types to calc navigation
+func WithIncludeAreas(areas ...mesh.AreaType) PathOption {
+	return func(o *graphs.NavOpts) {
+		o.IncludeAreas = areas
+	}
+}
+
+// WithExcludeAreas forbid listed area types to calc navigation
+func WithExcludeAreas(areas ...mesh.AreaType) PathOption {
+	return func(o *graphs.NavOpts) {
+		o.ExcludeAreas = areas
+	}
+}
+
+// WithAreaCosts override cost multipliers of area types to calc navigation
+// Multipliers below mesh.MinAreaCost are clamped to it
+func WithAreaCosts(costs mesh.AreaCosts) PathOption {
+	return func(o *graphs.NavOpts) {
+		o.AreaCosts = costs
+	}
+}
+
+// WithJumpPointSearch search path by Jump Point Search if graph supports it (uniform-cost grid)
+func WithJumpPointSearch() PathOption {
+	return func(o *graphs.NavOpts) {
+		o.JumpPointSearch = true
+	}
+}
+
+// WithSearcher set search algorithm (AStar, WeightedAStar, BidirectionalAStar, Dijkstra, ThetaStar or own one)
+// to calc navigation, graph search modes (triangle search, hierarchy, jump points) are skipped
+func WithSearcher[Node comparable](searcher Searcher[Node]) PathOption {
+	return func(o *graphs.NavOpts) {
+		o.Searcher = searcher
+	}
+}
+
+func newNavOpts(opts []PathOption) *graphs.NavOpts {
+	navOpts := &graphs.NavOpts{}
+	for _, opt := range opts {
+		opt(navOpts)
+	}
+
+	return navOpts
+}
@@ -3,6 +3,10 @@ package pathfind
 import (
 	"context"
 	"fmt"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
 
 	"github.com/bolom009/astar"
 	"github.com/bolom009/pathfind/graphs"
@@ -10,15 +14,66 @@ import (
 
 // Pathfinder represent struct to operate with pathfinding
 // Graph of current pathfinder represent as list of squares
+// After Initialize pathfinder is safe for concurrent use: paths could be searched from many goroutines
+// while obstacles of graphs are updated, each query sees graph before or after the update
 type Pathfinder[Node comparable] struct {
-	graphs []graphs.NavGraph[Node]
+	graphs      []graphs.NavGraph[Node]
+	initialized atomic.Bool
+	workers     int
+	// scratches keep A* buffers between searches
+	scratches sync.Pool
+	// cache keeps found paths if it's enabled by WithPathCache
+	cache *pathCache[Node]
+}
+
+// Path represent found path
+// Length is sum of graph costs of path segments, Costs contains cost of each segment.
+// Corridor contains indexes of graph cells (triangles, squares) which path goes through.
+// StartSnapped/DestSnapped show that point was out of graph area and search used SnappedStart/SnappedDest instead.
+// Links contains off-mesh links (jumps, ladders, teleporters) which path goes through
+type Path[Node comparable] struct {
+	Nodes        []Node
+	Length       float32
+	Costs        []float32
+	Corridor     []int32
+	StartSnapped bool
+	DestSnapped  bool
+	SnappedStart Node
+	SnappedDest  Node
+	Links        []graphs.PathLink
+}
+
+// PathRequest is query of PathBatch, it's searched like FindPath
+type PathRequest[Node comparable] struct {
+	GraphID int
+	Start   Node
+	Dest    Node
+	Options []PathOption
+}
+
+// PathResult is result of PathRequest with the same index
+type PathResult[Node comparable] struct {
+	Path Path[Node]
+	Err  error
 }
 
 // NewPathfinder constructor to create pathfinder struct
 // polygons contains two parts: polygon and holes
-func NewPathfinder[Node comparable](graphs []graphs.NavGraph[Node]) *Pathfinder[Node] {
+func NewPathfinder[Node comparable](graphs []graphs.NavGraph[Node], options ...Option) *Pathfinder[Node] {
+	o := pathfinderOptions{workers: runtime.GOMAXPROCS(0)}
+	for _, option := range options {
+		option(&o)
+	}
+
 	p := &Pathfinder[Node]{
-		graphs: graphs,
+		graphs:  graphs,
+		workers: max(o.workers, 1),
+	}
+	if o.cacheSize > 0 {
+		p.cache = newPathCache[Node](o.cacheSize)
+	}
+	p.scratches.New = func() any {
+		return newScratch[Node]()
 	}
 
 	return p
@@ -32,48 +87,178 @@ func (p *Pathfinder[Node]) Initialize(ctx context.Context) error {
 		}
 	}
 
+	if p.cache != nil {
+		p.cache.clear()
+	}
+
+	p.initialized.Store(true)
 	return nil
 }
 
+// CacheStats return counters of path cache, zero stats are returned if cache is disabled
+func (p *Pathfinder[Node]) CacheStats() PathCacheStats {
+	if p.cache == nil {
+		return PathCacheStats{}
+	}
+
+	return p.cache.statistics()
+}
+
+// FindPath finds the shortest path from start to dest like Path, but reports why path isn't found:
+// ErrUnknownGraph, ErrNotInitialized, ErrStartOutsideMesh, ErrDestOutsideMesh, ErrDestUnreachable or ctx error
+func (p *Pathfinder[Node]) FindPath(ctx context.Context, graphID int, start, dest Node, opts ...PathOption) (Path[Node], error) {
+	s := p.scratches.Get().(*scratch[Node])
+	defer p.scratches.Put(s)
+
+	return p.findPath(ctx, graphID, start, dest, newNavOpts(opts), s)
+}
+
+// PathBatch finds paths of requests by pool of workers, results are returned in order of requests
+// Each worker reuses its A* buffers for all requests which it takes. Requests which aren't started
+// before ctx is canceled get ctx error
+func (p *Pathfinder[Node]) PathBatch(ctx context.Context, requests []PathRequest[Node]) []PathResult[Node] {
+	var (
+		results = make([]PathResult[Node], len(requests))
+		next    atomic.Int64
+		wg      sync.WaitGroup
+	)
+
+	for range min(p.workers, len(requests)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			s := p.scratches.Get().(*scratch[Node])
+			defer p.scratches.Put(s)
+
+			for i := int(next.Add(1) - 1); i < len(requests); i = int(next.Add(1) - 1) {
+				r := requests[i]
+				results[i].Path, results[i].Err = p.findPath(ctx, r.GraphID, r.Start, r.Dest, newNavOpts(r.Options), s)
+			}
+		}()
+	}
+
+	wg.Wait()
+	return results
+}
+
+// findPath search path by scratch buffers and describe it or reason why it isn't found
+func (p *Pathfinder[Node]) findPath(ctx context.Context, graphID int, start, dest Node, navOpts *graphs.NavOpts, s *scratch[Node]) (Path[Node], error) {
+	if err := ctx.Err(); err != nil {
+		return Path[Node]{}, err
+	}
+
+	if !p.initialized.Load() {
+		return Path[Node]{}, ErrNotInitialized
+	}
+
+	g, err := p.graph(graphID)
+	if err != nil {
+		return Path[Node]{}, err
+	}
+
+	nodes := p.search(graphID, g, start, dest, navOpts, s)
+	if len(nodes) > 0 {
+		return newPath(g, nodes, navOpts), nil
+	}
+
+	return Path[Node]{}, notFoundError(g, start, dest)
+}
+
+// notFoundError describe why path isn't found
+func notFoundError[Node comparable](g graphs.NavGraph[Node], start, dest Node) error {
+	switch {
+	case !g.ContainsPoint(start):
+		return fmt.Errorf("%w: %v", ErrStartOutsideMesh, start)
+	case !g.ContainsPoint(dest):
+		return fmt.Errorf("%w: %v", ErrDestOutsideMesh, dest)
+	}
+
+	return fmt.Errorf("%w: from %v to %v", ErrDestUnreachable, start, dest)
+}
+
 // Path finds the shortest path from start to dest
 // To search path could be added dynamic obstacles. All obstacles will cut current graph by their polygon.
 // The function returns nil if no path exists
 func (p *Pathfinder[Node]) Path(graphID int, start, dest Node, opts ...PathOption) []Node {
-	g := p.graphs[graphID]
-	if g == nil {
+	g, err := p.graph(graphID)
+	if err != nil {
 		return nil
 	}
 
-	navOpts := &graphs.NavOpts{}
-	for _, opt := range opts {
-		opt(navOpts)
+	s := p.scratches.Get().(*scratch[Node])
+	defer p.scratches.Put(s)
+
+	return p.search(graphID, g, start, dest, newNavOpts(opts), s)
+}
+
+// search return cached path if cache is enabled and graph wasn't changed in path area, otherwise path is searched
+// and cached, graph version is read before search, so path found on older graph data is checked by later changes
+func (p *Pathfinder[Node]) search(graphID int, g graphs.NavGraph[Node], start, dest Node, navOpts *graphs.NavOpts, s *scratch[Node]) []Node {
+	if p.cache == nil || !cacheable(navOpts) {
+		return p.searchGraph(g, start, dest, navOpts, s)
+	}
+
+	key := newPathKey(graphID, g, start, dest, navOpts)
+	if nodes, ok := p.cache.get(g, key); ok {
+		return slices.Clone(nodes)
 	}
 
-	vis := g.AggregationGraph(start, dest, nil)
+	var version uint64
+	if tracker, ok := g.(graphs.ChangeTracker[Node]); ok {
+		version = tracker.Version()
+	}
+
+	nodes := p.searchGraph(g, start, dest, navOpts, s)
+	if len(nodes) > 0 {
+		p.cache.put(key, slices.Clone(nodes), version)
+	}
+
+	return nodes
+}
+
+// searchGraph finds path by searcher of query over aggregation graph, if query doesn't set searcher
+// graph search modes (PathSearcher) are tried before A*
+func (p *Pathfinder[Node]) searchGraph(g graphs.NavGraph[Node], start, dest Node, navOpts *graphs.NavOpts, s *scratch[Node]) []Node {
+	searcher, ok := navOpts.Searcher.(Searcher[Node])
+	if !ok {
+		if pathSearcher, ok := g.(graphs.PathSearcher[Node]); ok {
+			if path, ok := pathSearcher.SearchPath(start, dest, navOpts); ok {
+				return path
+			}
+		}
+
+		searcher = AStar[Node]()
+	}
+
+	vis := g.AggregationGraph(start, dest, navOpts)
 
 	// check if start-dest graph then skip A* search path
 	if len(vis) == 2 && vis[start][0] == dest && vis[dest][0] == start {
 		return []Node{start, dest}
 	}
 
-	path := astar.FindPath[Node](vis, start, dest, g.HashIndex, g.Cost, g.Cost)
-
-	return path
+	cost, heuristic := costFuncs(g, navOpts)
+	return searcher.Search(&SearchQuery[Node]{
+		Graph:       vis,
+		Start:       start,
+		Dest:        dest,
+		Cost:        cost,
+		Heuristic:   heuristic,
+		Hash:        g.HashIndex,
+		LineOfSight: lineOfSight(g, navOpts),
+		scratch:     s,
+	})
 }
 
 // Graph return generated graph visibility
 func (p *Pathfinder[Node]) Graph(graphID int, opts ...PathOption) map[Node][]Node {
-	g := p.graphs[graphID]
-	if g == nil {
+	g, err := p.graph(graphID)
+	if err != nil {
 		return nil
 	}
 
-	navOpts := &graphs.NavOpts{}
-	for _, opt := range opts {
-		opt(navOpts)
-	}
-
-	return g.GetVisibility(navOpts)
+	return g.GetVisibility(newNavOpts(opts))
 }
 
 func (p *Pathfinder[Node]) GraphsNum() int {
@@ -81,8 +266,8 @@ func (p *Pathfinder[Node]) GraphsNum() int {
 }
 
 func (p *Pathfinder[Node]) GetClosestPoint(graphID int, point Node) (Node, bool) {
-	g := p.graphs[graphID]
-	if g == nil {
+	g, err := p.graph(graphID)
+	if err != nil {
 		var zero Node
 		return zero, false
 	}
@@ -91,8 +276,8 @@ func (p *Pathfinder[Node]) GetClosestPoint(graphID int, point Node) (Node, bool)
 }
 
 func (p *Pathfinder[Node]) IsRaycastHit(graphID int, start, dest Node) bool {
-	g := p.graphs[graphID]
-	if g == nil {
+	g, err := p.graph(graphID)
+	if err != nil {
 		return false
 	}
 
@@ -101,15 +286,71 @@ func (p *Pathfinder[Node]) IsRaycastHit(graphID int, start, dest Node) bool {
 
 // GraphWithSearchPath return generated graph with path nodes
 func (p *Pathfinder[Node]) GraphWithSearchPath(graphID int, start, dest Node, opts ...PathOption) graphs.Graph[Node] {
-	g := p.graphs[graphID]
-	if g == nil {
+	g, err := p.graph(graphID)
+	if err != nil {
 		return nil
 	}
 
-	navOpts := &graphs.NavOpts{}
-	for _, opt := range opts {
-		opt(navOpts)
+	return g.AggregationGraph(start, dest, newNavOpts(opts))
+}
+
+// newPath describe found nodes by costs of graph, corridor, snapped points and links are added if graph supports them
+func newPath[Node comparable](g graphs.NavGraph[Node], nodes []Node, navOpts *graphs.NavOpts) Path[Node] {
+	path := Path[Node]{
+		Nodes:        nodes,
+		Costs:        make([]float32, max(len(nodes)-1, 0)),
+		SnappedStart: nodes[0],
+		SnappedDest:  nodes[len(nodes)-1],
+	}
+
+	cost, _ := costFuncs(g, navOpts)
+	for i := 0; i < len(nodes)-1; i++ {
+		path.Costs[i] = cost(nodes[i], nodes[i+1])
+		path.Length += path.Costs[i]
+	}
+
+	if describer, ok := g.(graphs.PathDescriber[Node]); ok {
+		path.Corridor = describer.Corridor(nodes, navOpts)
+		path.SnappedStart, path.StartSnapped = describer.SnapPoint(path.SnappedStart, navOpts)
+		path.SnappedDest, path.DestSnapped = describer.SnapPoint(path.SnappedDest, navOpts)
+	}
+
+	if linker, ok := g.(graphs.PathLinker[Node]); ok {
+		path.Links = linker.PathLinks(nodes, navOpts)
+	}
+
+	return path
+}
+
+// costFuncs return cost and heuristic functions of graph for query
+func costFuncs[Node comparable](g graphs.NavGraph[Node], navOpts *graphs.NavOpts) (cost, heuristic astar.CostFunc[Node]) {
+	if coster, ok := g.(graphs.QueryCoster[Node]); ok {
+		return coster.QueryCosts(navOpts)
+	}
+
+	if estimator, ok := g.(graphs.Estimator[Node]); ok {
+		return g.Cost, estimator.Estimate
+	}
+
+	return g.Cost, g.Cost
+}
+
+// lineOfSight return check of straight moves of query, graph raycast is used if graph can't check walkable area
+func lineOfSight[Node comparable](g graphs.NavGraph[Node], navOpts *graphs.NavOpts) func(a, b Node) bool {
+	if checker, ok := g.(graphs.SightChecker[Node]); ok {
+		return checker.LineOfSight(navOpts)
+	}
+
+	return func(a, b Node) bool {
+		return !g.IsRaycastHit(a, b)
+	}
+}
+
+// graph return graph by ID with bounds check
+func (p *Pathfinder[Node]) graph(graphID int) (graphs.NavGraph[Node], error) {
+	if graphID < 0 || graphID >= len(p.graphs) || p.graphs[graphID] == nil {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownGraph, graphID)
 	}
 
-	return g.AggregationGraph(start, dest, navOpts)
+	return p.graphs[graphID], nil
 }
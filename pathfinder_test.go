@@ -1,13 +1,26 @@
 package pathfind
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"math/rand"
+	"sync"
 	"testing"
 
 	"github.com/bolom009/geom"
 	"github.com/bolom009/pathfind/demo/utils"
 	"github.com/bolom009/pathfind/graphs"
+	"github.com/bolom009/pathfind/graphs/grid"
+	"github.com/bolom009/pathfind/graphs/hex"
 	"github.com/bolom009/pathfind/graphs/recast"
 	"github.com/bolom009/pathfind/mesh"
+	"github.com/bolom009/pathfind/obstacles"
+	"github.com/stretchr/testify/assert"
 )
 
 const floorPlan = `{"canvas":{"w":800,"h":600},"polygons":[[{"x":0,"y":0},{"x":120,"y":0},{"x":120,"y":340},{"x":180,"y":340},{"x":180,"y":-120},{"x":300,"y":-120},{"x":300,"y":340},{"x":360,"y":340},{"x":360,"y":0},{"x":480,"y":0},{"x":480,"y":420},{"x":300,"y":420},{"x":300,"y":540},{"x":340,"y":540},{"x":340,"y":720},{"x":140,"y":720},{"x":140,"y":540},{"x":180,"y":540},{"x":180,"y":420},{"x":0,"y":420}]]}`
@@ -15,6 +28,7 @@ const floorPlan = `{"canvas":{"w":800,"h":600},"polygons":[[{"x":0,"y":0},{"x":1
 // BenchmarkPathfinder_Path-16    	  106706	     10626 ns/op	    9800 B/op	     197 allocs/op
 // BenchmarkPathfinder_Path-16    	  118738	      9630 ns/op	    8256 B/op	     162 allocs/op
 // BenchmarkPathfinder_Path-16    	  125618	      9456 ns/op	    8256 B/op	     162 allocs/op
+// BenchmarkPathfinder_Path       	  196710	      6753 ns/op	    2168 B/op	      23 allocs/op
 func BenchmarkPathfinder_Path(b *testing.B) {
 	polygon, holes, _, err := utils.NewPolygonsFromJSON([]byte(floorPlan))
 	if err != nil {
@@ -53,3 +67,1354 @@ func BenchmarkPathfinder_Path(b *testing.B) {
 		_ = pathfinder.Path(0, start, dest)
 	}
 }
+
+// BenchmarkPathfinder_PathTriangleSearch-16    	  379816	      2797 ns/op	    3792 B/op	      17 allocs/op
+func BenchmarkPathfinder_PathTriangleSearch(b *testing.B) {
+	polygon, holes, _, err := utils.NewPolygonsFromJSON([]byte(floorPlan))
+	if err != nil {
+		panic(err)
+	}
+
+	nHoles := make([]*mesh.Hole, len(holes))
+	for i, hole := range holes {
+		nHoles[i] = mesh.NewObstacle(hole, -5.0, true)
+	}
+
+	var (
+		start       = geom.Vector2{X: 60, Y: 10}
+		dest        = geom.Vector2{X: 425, Y: 10}
+		recastGraph = recast.NewRecast([]*mesh.Polygon{
+			mesh.NewPolygon(polygon, nil, nHoles, 35),
+		}, recast.WithSearchOutOfArea(true), recast.WithTriangleSearch(true))
+		pathfinder = NewPathfinder[geom.Vector2]([]graphs.NavGraph[geom.Vector2]{
+			recastGraph,
+		})
+	)
+
+	_ = pathfinder.Initialize(nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = pathfinder.Path(0, start, dest)
+	}
+}
+
+// BenchmarkPathfinder_PathLargeGrid/flat           	      45	  25377144 ns/op	 2742174 B/op	     165 allocs/op
+// BenchmarkPathfinder_PathLargeGrid/hierarchy      	    1976	    567706 ns/op	  121651 B/op	    1468 allocs/op
+// BenchmarkPathfinder_PathLargeGrid/jump_points    	    1593	    796542 ns/op	    9891 B/op	      63 allocs/op
+func BenchmarkPathfinder_PathLargeGrid(b *testing.B) {
+	var (
+		room  = []geom.Vector2{{X: 0, Y: 0}, {X: 400, Y: 0}, {X: 400, Y: 400}, {X: 0, Y: 400}}
+		walls = [][]geom.Vector2{
+			{{X: 100, Y: -10}, {X: 110, Y: -10}, {X: 110, Y: 300}, {X: 100, Y: 300}},
+			{{X: 250, Y: 100}, {X: 260, Y: 100}, {X: 260, Y: 410}, {X: 250, Y: 410}},
+		}
+		start = geom.Vector2{X: 15, Y: 15}
+		dest  = geom.Vector2{X: 385, Y: 385}
+	)
+
+	for _, bc := range []struct {
+		name  string
+		graph *grid.Grid
+	}{
+		{name: "flat", graph: grid.NewGrid(room, walls, 2)},
+		{name: "hierarchy", graph: grid.NewGrid(room, walls, 2, grid.WithHierarchy(16))},
+		{name: "jump_points", graph: grid.NewGrid(room, walls, 2, grid.WithJumpPointSearch(true))},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			pathfinder := NewPathfinder[geom.Vector2]([]graphs.NavGraph[geom.Vector2]{bc.graph})
+			_ = pathfinder.Initialize(context.Background())
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				_ = pathfinder.Path(0, start, dest)
+			}
+		})
+	}
+}
+
+func TestPathfinder_PathWithObstacles(t *testing.T) {
+	var (
+		room = []geom.Vector2{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 100}, {X: 0, Y: 100}}
+		wall = obstacles.GenerateRectangle(geom.Vector2{X: 50, Y: 50}, 20, 60)
+
+		start = geom.Vector2{X: 20, Y: 50}
+		dest  = geom.Vector2{X: 80, Y: 50}
+	)
+
+	navGraphs := []graphs.NavGraph[geom.Vector2]{
+		recast.NewRecast([]*mesh.Polygon{mesh.NewPolygon(room, nil, nil, 0)}),
+		grid.NewGrid(room, nil, 5),
+	}
+
+	pathfinder := NewPathfinder[geom.Vector2](navGraphs)
+	if err := pathfinder.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	for graphID := range navGraphs {
+		path := pathfinder.Path(graphID, start, dest)
+		assert.NotEmpty(t, path)
+		assert.True(t, isPathIntersectsRect(path, 40, 20, 60, 80))
+
+		path = pathfinder.Path(graphID, start, dest, WithObstacles([]obstacles.Obstacle{wall}))
+		assert.NotEmpty(t, path)
+		assert.Equal(t, start, path[0])
+		assert.Equal(t, dest, path[len(path)-1])
+		assert.False(t, isPathIntersectsRect(path, 40, 20, 60, 80))
+
+		path = pathfinder.Path(graphID, start, dest, WithObstacles([]obstacles.Obstacle{wall}), WithAgentRadius(4))
+		assert.NotEmpty(t, path)
+		assert.False(t, isPathIntersectsRect(path, 37, 17, 63, 83))
+	}
+}
+
+// BenchmarkGrid_Lookups/closest_point/100         	 2350309	       500.1 ns/op	       0 B/op	       0 allocs/op
+// BenchmarkGrid_Lookups/corridor/100              	 2871399	       423.2 ns/op	     152 B/op	       5 allocs/op
+// BenchmarkGrid_Lookups/closest_point/400         	 2615802	       494.3 ns/op	       0 B/op	       0 allocs/op
+// BenchmarkGrid_Lookups/corridor/400              	 2847609	       427.3 ns/op	     152 B/op	       5 allocs/op
+// BenchmarkGrid_Lookups/closest_point/1000        	 2518538	       498.2 ns/op	       0 B/op	       0 allocs/op
+// BenchmarkGrid_Lookups/corridor/1000             	 2645749	       453.0 ns/op	     152 B/op	       5 allocs/op
+func BenchmarkGrid_Lookups(b *testing.B) {
+	var (
+		point = geom.Vector2{X: 55.3, Y: -7.1}
+		path  = []geom.Vector2{{X: 55.3, Y: 7.1}, {X: 56, Y: 8}, {X: 58, Y: 10}, {X: 60, Y: 10}, {X: 62, Y: 12}}
+	)
+
+	for _, size := range []float32{100, 400, 1000} {
+		var (
+			room  = []geom.Vector2{{X: 0, Y: 0}, {X: size, Y: 0}, {X: size, Y: size}, {X: 0, Y: size}}
+			graph = grid.NewGrid(room, nil, 2)
+		)
+
+		_ = graph.Generate(context.Background())
+
+		b.Run(fmt.Sprintf("closest_point/%.0f", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, _ = graph.GetClosestPoint(point)
+			}
+		})
+
+		b.Run(fmt.Sprintf("corridor/%.0f", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = graph.Corridor(path, nil)
+			}
+		})
+	}
+}
+
+// 256 requests per op, measured on 1 CPU (batch gains with count of CPUs)
+// BenchmarkPathfinder_PathBatch/sequential    	     772	   1532728 ns/op	  535593 B/op	    6315 allocs/op
+// BenchmarkPathfinder_PathBatch/batch         	     829	   1696513 ns/op	  577028 B/op	    6322 allocs/op
+func BenchmarkPathfinder_PathBatch(b *testing.B) {
+	polygon, holes, _, err := utils.NewPolygonsFromJSON([]byte(floorPlan))
+	if err != nil {
+		panic(err)
+	}
+
+	nHoles := make([]*mesh.Hole, len(holes))
+	for i, hole := range holes {
+		nHoles[i] = mesh.NewObstacle(hole, -5.0, true)
+	}
+
+	var (
+		ctx         = context.Background()
+		recastGraph = recast.NewRecast([]*mesh.Polygon{
+			mesh.NewPolygon(polygon, nil, nHoles, 35),
+		}, recast.WithSearchOutOfArea(true))
+		pathfinder = NewPathfinder[geom.Vector2]([]graphs.NavGraph[geom.Vector2]{
+			recastGraph,
+		})
+		requests = make([]PathRequest[geom.Vector2], 256)
+	)
+
+	_ = pathfinder.Initialize(ctx)
+
+	for i := range requests {
+		requests[i] = PathRequest[geom.Vector2]{
+			Start: geom.Vector2{X: 60, Y: float32(10 + i%64*5)},
+			Dest:  geom.Vector2{X: 425, Y: float32(10 + i/64*50)},
+		}
+	}
+
+	b.Run("sequential", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, r := range requests {
+				_, _ = pathfinder.FindPath(ctx, r.GraphID, r.Start, r.Dest)
+			}
+		}
+	})
+
+	b.Run("batch", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = pathfinder.PathBatch(ctx, requests)
+		}
+	})
+}
+
+func TestPathfinder_ConcurrentPath(t *testing.T) {
+	var (
+		room = []geom.Vector2{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 100}, {X: 0, Y: 100}}
+		wall = obstacles.GenerateRectangle(geom.Vector2{X: 50, Y: 50}, 20, 60)
+
+		start = geom.Vector2{X: 20, Y: 50}
+		dest  = geom.Vector2{X: 80, Y: 50}
+	)
+
+	navGraphs := []graphs.NavGraph[geom.Vector2]{
+		recast.NewRecast([]*mesh.Polygon{mesh.NewPolygon(room, nil, nil, 0)}),
+		recast.NewRecast([]*mesh.Polygon{mesh.NewPolygon(room, nil, nil, 0)},
+			recast.WithTileSize(25), recast.WithTriangleSearch(true), recast.WithAgentRadii(2)),
+		grid.NewGrid(room, nil, 5),
+	}
+
+	pathfinder := NewPathfinder[geom.Vector2](navGraphs)
+	if err := pathfinder.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for graphID, g := range navGraphs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 20 {
+				ids := g.AddObstacles(mesh.NewObstacle(wall.GetPolygon(), 1, false))
+				g.RemoveObstacles(ids...)
+			}
+		}()
+
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 20 {
+					assert.NotEmpty(t, pathfinder.Path(graphID, start, dest))
+					assert.NotEmpty(t, pathfinder.Path(graphID, start, dest, WithObstacles([]obstacles.Obstacle{wall}), WithAgentRadius(2)))
+					assert.NotEmpty(t, pathfinder.Graph(graphID))
+					_, _ = pathfinder.GetClosestPoint(graphID, geom.Vector2{X: -10, Y: 50})
+				}
+			}()
+		}
+	}
+
+	wg.Wait()
+}
+
+func TestPathfinder_PathBatch(t *testing.T) {
+	var (
+		room = []geom.Vector2{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 100}, {X: 0, Y: 100}}
+		wall = []geom.Vector2{{X: 40, Y: 20}, {X: 60, Y: 20}, {X: 60, Y: 100}, {X: 40, Y: 100}}
+		ctx  = context.Background()
+	)
+
+	pathfinder := NewPathfinder[geom.Vector2]([]graphs.NavGraph[geom.Vector2]{
+		recast.NewRecast([]*mesh.Polygon{mesh.NewPolygon(room, []*mesh.Hole{mesh.NewInnerHole(wall, 0)}, nil, 0)}),
+		grid.NewGrid(room, [][]geom.Vector2{wall}, 10),
+	}, WithWorkers(3))
+
+	requests := []PathRequest[geom.Vector2]{
+		{GraphID: 0, Start: geom.Vector2{X: -10, Y: 50}, Dest: geom.Vector2{X: 80, Y: 50}},
+	}
+	assert.ErrorIs(t, pathfinder.PathBatch(ctx, requests)[0].Err, ErrNotInitialized)
+
+	if err := pathfinder.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 20 {
+		requests = append(requests, PathRequest[geom.Vector2]{
+			GraphID: i % 2,
+			Start:   geom.Vector2{X: 15, Y: float32(15 + i*3)},
+			Dest:    geom.Vector2{X: 85, Y: float32(75 - i*3)},
+		})
+	}
+	requests = append(requests,
+		PathRequest[geom.Vector2]{GraphID: 2, Start: geom.Vector2{X: 15, Y: 15}, Dest: geom.Vector2{X: 85, Y: 15}},
+		PathRequest[geom.Vector2]{GraphID: 1, Start: geom.Vector2{X: 15, Y: 15}, Dest: geom.Vector2{X: 85, Y: 15},
+			Options: []PathOption{WithObstacles([]obstacles.Obstacle{obstacles.GenerateRectangle(geom.Vector2{X: 50, Y: 10}, 100, 30)})}},
+	)
+
+	results := pathfinder.PathBatch(ctx, requests)
+	assert.Len(t, results, len(requests))
+	for i, r := range requests {
+		path, err := pathfinder.FindPath(ctx, r.GraphID, r.Start, r.Dest, r.Options...)
+		assert.Equal(t, path, results[i].Path, i)
+		assert.Equal(t, err, results[i].Err, i)
+	}
+
+	assert.ErrorIs(t, results[0].Err, ErrStartOutsideMesh)
+	assert.NotEmpty(t, results[1].Path.Nodes)
+	assert.ErrorIs(t, results[len(results)-2].Err, ErrUnknownGraph)
+	assert.ErrorIs(t, results[len(results)-1].Err, ErrDestUnreachable)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	for _, result := range pathfinder.PathBatch(canceled, requests) {
+		assert.ErrorIs(t, result.Err, context.Canceled)
+	}
+
+	assert.Empty(t, pathfinder.PathBatch(ctx, nil))
+}
+
+func TestPathfinder_PathCache(t *testing.T) {
+	var (
+		room  = []geom.Vector2{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 100}, {X: 0, Y: 100}}
+		wall  = []geom.Vector2{{X: 40, Y: 20}, {X: 60, Y: 20}, {X: 60, Y: 100}, {X: 40, Y: 100}}
+		start = geom.Vector2{X: 15, Y: 15}
+		dest  = geom.Vector2{X: 85, Y: 15}
+		ctx   = context.Background()
+	)
+
+	recastGraph := recast.NewRecast([]*mesh.Polygon{mesh.NewPolygon(room, []*mesh.Hole{mesh.NewInnerHole(wall, 0)}, nil, 0)})
+	pathfinder := NewPathfinder[geom.Vector2]([]graphs.NavGraph[geom.Vector2]{recastGraph}, WithPathCache(8))
+	if err := pathfinder.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	path := pathfinder.Path(0, start, dest)
+	assert.Equal(t, []geom.Vector2{start, dest}, path)
+	assert.Equal(t, path, pathfinder.Path(0, start, dest))
+	assert.Equal(t, PathCacheStats{Hits: 1, Misses: 1, Size: 1}, pathfinder.CacheStats())
+
+	// queries with obstacles aren't cached
+	obstacle := obstacles.GenerateRectangle(geom.Vector2{X: 50, Y: 10}, 100, 30)
+	assert.Empty(t, pathfinder.Path(0, start, dest, WithObstacles([]obstacles.Obstacle{obstacle})))
+	assert.Equal(t, PathCacheStats{Hits: 1, Misses: 1, Size: 1}, pathfinder.CacheStats())
+
+	// obstacle out of path area keeps cached path
+	far := recastGraph.AddObstacles(mesh.NewObstacle([]geom.Vector2{{X: 10, Y: 70}, {X: 20, Y: 70}, {X: 20, Y: 80}, {X: 10, Y: 80}}, 0, false))
+	assert.Equal(t, path, pathfinder.Path(0, start, dest))
+	assert.Equal(t, PathCacheStats{Hits: 2, Misses: 1, Size: 1}, pathfinder.CacheStats())
+
+	// obstacle on path drops cached path
+	near := recastGraph.AddObstacles(mesh.NewObstacle([]geom.Vector2{{X: 48, Y: 5}, {X: 52, Y: 5}, {X: 52, Y: 18}, {X: 48, Y: 18}}, 0, false))
+	detour := pathfinder.Path(0, start, dest)
+	assert.Greater(t, len(detour), 2)
+	assert.Equal(t, PathCacheStats{Hits: 2, Misses: 2, Invalidations: 1, Size: 1}, pathfinder.CacheStats())
+	assert.Equal(t, detour, pathfinder.Path(0, start, dest))
+
+	// removed obstacle could open shorter path anywhere
+	recastGraph.RemoveObstacles(far...)
+	assert.Equal(t, detour, pathfinder.Path(0, start, dest))
+	recastGraph.RemoveObstacles(near...)
+	assert.Equal(t, path, pathfinder.Path(0, start, dest))
+	assert.Equal(t, PathCacheStats{Hits: 3, Misses: 4, Invalidations: 3, Size: 1}, pathfinder.CacheStats())
+
+	// cached path is a copy
+	path = pathfinder.Path(0, start, dest)
+	path[0] = geom.Vector2{}
+	assert.Equal(t, start, pathfinder.Path(0, start, dest)[0])
+
+	// time-sliced query neither reads nor writes cache
+	stats := pathfinder.CacheStats()
+	for _, dest := range []geom.Vector2{dest, {X: 85, Y: 85}} {
+		query, err := pathfinder.BeginPath(0, start, dest)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		query.Step(0)
+		_, err = query.Result()
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, stats, pathfinder.CacheStats())
+
+	assert.Equal(t, PathCacheStats{}, NewPathfinder[geom.Vector2](nil).CacheStats())
+}
+
+func TestPathfinder_PathCacheSearchModes(t *testing.T) {
+	var (
+		room  = []geom.Vector2{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 100}, {X: 0, Y: 100}}
+		wall  = []geom.Vector2{{X: 40, Y: 20}, {X: 60, Y: 20}, {X: 60, Y: 100}, {X: 40, Y: 100}}
+		start = geom.Vector2{X: 15, Y: 15}
+		dest  = geom.Vector2{X: 85, Y: 85}
+	)
+
+	pathfinder := NewPathfinder[geom.Vector2]([]graphs.NavGraph[geom.Vector2]{grid.NewGrid(room, [][]geom.Vector2{wall}, 2)}, WithPathCache(8))
+	if err := pathfinder.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// JPS and A* queries don't share cached paths
+	flat := pathfinder.Path(0, start, dest)
+	jps := pathfinder.Path(0, start, dest, WithJumpPointSearch())
+	assert.NotEmpty(t, flat)
+	assert.NotEmpty(t, jps)
+	assert.Equal(t, PathCacheStats{Misses: 2, Size: 2}, pathfinder.CacheStats())
+
+	assert.Equal(t, flat, pathfinder.Path(0, start, dest))
+	assert.Equal(t, jps, pathfinder.Path(0, start, dest, WithJumpPointSearch()))
+	assert.Equal(t, PathCacheStats{Hits: 2, Misses: 2, Size: 2}, pathfinder.CacheStats())
+
+	// points are keyed exactly
+	assert.NotEmpty(t, pathfinder.Path(0, start, geom.Vector2{X: dest.X + 1e-3, Y: dest.Y}))
+	assert.Equal(t, PathCacheStats{Hits: 2, Misses: 3, Size: 3}, pathfinder.CacheStats())
+}
+
+func TestPathfinder_FindPath(t *testing.T) {
+	var (
+		roomA = []geom.Vector2{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 100}, {X: 0, Y: 100}}
+		roomB = []geom.Vector2{{X: 200, Y: 0}, {X: 300, Y: 0}, {X: 300, Y: 100}, {X: 200, Y: 100}}
+
+		ctx = context.Background()
+	)
+
+	pathfinder := NewPathfinder[geom.Vector2]([]graphs.NavGraph[geom.Vector2]{
+		recast.NewRecast([]*mesh.Polygon{mesh.NewPolygon(roomA, nil, nil, 0), mesh.NewPolygon(roomB, nil, nil, 0)}),
+	})
+
+	_, err := pathfinder.FindPath(ctx, 0, geom.Vector2{X: 10, Y: 10}, geom.Vector2{X: 90, Y: 90})
+	assert.ErrorIs(t, err, ErrNotInitialized)
+
+	if err = pathfinder.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		graphID int
+		start   geom.Vector2
+		dest    geom.Vector2
+		wantErr error
+	}{
+		{name: "found", ctx: ctx, start: geom.Vector2{X: 10, Y: 10}, dest: geom.Vector2{X: 90, Y: 90}},
+		{name: "negative graph id", ctx: ctx, graphID: -1, wantErr: ErrUnknownGraph},
+		{name: "graph id out of range", ctx: ctx, graphID: 1, wantErr: ErrUnknownGraph},
+		{name: "start outside", ctx: ctx, start: geom.Vector2{X: 150, Y: 10}, dest: geom.Vector2{X: 90, Y: 90}, wantErr: ErrStartOutsideMesh},
+		{name: "dest outside", ctx: ctx, start: geom.Vector2{X: 10, Y: 10}, dest: geom.Vector2{X: 150, Y: 10}, wantErr: ErrDestOutsideMesh},
+		{name: "unreachable", ctx: ctx, start: geom.Vector2{X: 10, Y: 10}, dest: geom.Vector2{X: 250, Y: 50}, wantErr: ErrDestUnreachable},
+		{name: "canceled", ctx: canceledCtx, start: geom.Vector2{X: 10, Y: 10}, dest: geom.Vector2{X: 90, Y: 90}, wantErr: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := pathfinder.FindPath(tt.ctx, tt.graphID, tt.start, tt.dest)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, path.Nodes)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, []geom.Vector2{tt.start, tt.dest}, path.Nodes)
+		})
+	}
+
+	assert.Nil(t, pathfinder.Path(5, geom.Vector2{}, geom.Vector2{}))
+	assert.Nil(t, pathfinder.Graph(-1))
+}
+
+func TestPathfinder_FindPathDescription(t *testing.T) {
+	var (
+		room  = []geom.Vector2{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 100}, {X: 0, Y: 100}}
+		wall  = []geom.Vector2{{X: 40, Y: 20}, {X: 60, Y: 20}, {X: 60, Y: 100}, {X: 40, Y: 100}}
+		start = geom.Vector2{X: -10, Y: 50}
+		dest  = geom.Vector2{X: 80, Y: 50}
+		ctx   = context.Background()
+	)
+
+	pathfinder := NewPathfinder[geom.Vector2]([]graphs.NavGraph[geom.Vector2]{
+		recast.NewRecast([]*mesh.Polygon{mesh.NewPolygon(room, []*mesh.Hole{mesh.NewInnerHole(wall, 0)}, nil, 0)},
+			recast.WithSearchOutOfArea(true)),
+		grid.NewGrid(room, [][]geom.Vector2{wall}, 10),
+	})
+	if err := pathfinder.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := pathfinder.FindPath(ctx, 0, start, dest)
+	assert.NoError(t, err)
+	assert.True(t, path.StartSnapped)
+	assert.False(t, path.DestSnapped)
+	assert.Equal(t, geom.Vector2{X: 0, Y: 50}, path.SnappedStart)
+	assert.Equal(t, dest, path.SnappedDest)
+	assert.NotEmpty(t, path.Corridor)
+	assertPathCosts(t, path)
+
+	// start is inside of grid square
+	start = geom.Vector2{X: 15, Y: 55}
+	path, err = pathfinder.FindPath(ctx, 1, start, dest)
+	assert.NoError(t, err)
+	assert.False(t, path.StartSnapped)
+	assert.Equal(t, start, path.SnappedStart)
+	assert.Len(t, path.Corridor, len(path.Nodes)-1)
+	assertPathCosts(t, path)
+}
+
+func TestPathfinder_FindPathLinks(t *testing.T) {
+	var (
+		left   = []geom.Vector2{{X: 0, Y: 0}, {X: 20, Y: 0}, {X: 20, Y: 20}, {X: 0, Y: 20}}
+		right  = []geom.Vector2{{X: 40, Y: 0}, {X: 60, Y: 0}, {X: 60, Y: 20}, {X: 40, Y: 20}}
+		start  = geom.Vector2{X: 5, Y: 10}
+		dest   = geom.Vector2{X: 55, Y: 10}
+		ladder = recast.OffMeshLink{Start: geom.Vector2{X: 18, Y: 10}, End: geom.Vector2{X: 42, Y: 10}, Cost: 30, Meta: "ladder"}
+		ctx    = context.Background()
+	)
+
+	recastGraph := recast.NewRecast([]*mesh.Polygon{
+		mesh.NewPolygon(left, nil, nil, 0),
+		mesh.NewPolygon(right, nil, nil, 0),
+	})
+	pathfinder := NewPathfinder[geom.Vector2]([]graphs.NavGraph[geom.Vector2]{recastGraph})
+	if err := pathfinder.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := pathfinder.FindPath(ctx, 0, start, dest)
+	assert.ErrorIs(t, err, ErrDestUnreachable)
+
+	ids := recastGraph.AddOffMeshLinks(ladder)
+	path, err := pathfinder.FindPath(ctx, 0, start, dest)
+	assert.NoError(t, err)
+	assert.Equal(t, []geom.Vector2{start, ladder.Start, ladder.End, dest}, path.Nodes)
+	assert.Equal(t, []graphs.PathLink{{Segment: 1, ID: ids[0], Meta: "ladder"}}, path.Links)
+	assert.InDelta(t, 13+30+13, path.Length, 1e-3)
+}
+
+func TestPathfinder_FindPathAreaCosts(t *testing.T) {
+	const (
+		road mesh.AreaType = iota + 1
+		mud
+	)
+
+	var (
+		room  = []geom.Vector2{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 60}, {X: 0, Y: 60}}
+		swamp = mesh.NewArea([]geom.Vector2{{X: 30, Y: 10}, {X: 70, Y: 10}, {X: 70, Y: 60}, {X: 30, Y: 60}}, mud)
+		way   = mesh.NewArea([]geom.Vector2{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 10}, {X: 0, Y: 10}}, road)
+		costs = mesh.AreaCosts{road: 0.5, mud: 5}
+		start = geom.Vector2{X: 15, Y: 35}
+		dest  = geom.Vector2{X: 85, Y: 35}
+		ctx   = context.Background()
+	)
+
+	gridGraph := grid.NewGrid(room, nil, 10, grid.WithAreas(way, swamp), grid.WithAreaCosts(costs))
+	pathfinder := NewPathfinder[geom.Vector2]([]graphs.NavGraph[geom.Vector2]{
+		recast.NewRecast([]*mesh.Polygon{mesh.NewPolygon(room, nil, nil, 0).AddAreas(way, swamp)}, recast.WithAreaCosts(costs)),
+		gridGraph,
+	})
+	if err := pathfinder.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	squareAreas := make(map[mesh.AreaType]int)
+	for _, square := range gridGraph.VisibleSquares() {
+		squareAreas[square.Area]++
+	}
+	assert.Len(t, squareAreas, 3)
+
+	for graphID := range 2 {
+		path, err := pathfinder.FindPath(ctx, graphID, start, dest)
+		assert.NoError(t, err)
+
+		// path goes around the swamp over the road
+		assert.Less(t, path.Length, 40+40*float32(5))
+		for _, node := range path.Nodes {
+			if node.X > 30 && node.X < 70 {
+				assert.LessOrEqual(t, node.Y, float32(10))
+			}
+		}
+
+		// non-positive costs are clamped, so path goes over the swamp instead of the road with positive costs
+		path, err = pathfinder.FindPath(ctx, graphID, start, dest, WithAreaCosts(mesh.AreaCosts{mesh.AreaDefault: 0, mud: -5}))
+		assert.NoError(t, err)
+		assert.Less(t, path.Length, 70*float32(0.5))
+		for _, cost := range path.Costs {
+			assert.Greater(t, cost, float32(0))
+		}
+	}
+}
+
+func TestPathfinder_GridCostMap(t *testing.T) {
+	var (
+		img   = image.NewGray(image.Rect(0, 0, 10, 6))
+		costs = make([][]float32, 6)
+		start = geom.Vector2{X: 15, Y: 35}
+		dest  = geom.Vector2{X: 85, Y: 35}
+		ctx   = context.Background()
+		buf   bytes.Buffer
+	)
+
+	// white road on the first row, gray mud below it is split by black wall
+	for y := range costs {
+		costs[y] = make([]float32, 10)
+		for x := range costs[y] {
+			gray := uint8(51)
+			switch {
+			case y == 0:
+				gray = 255
+			case x == 5 && y < 5:
+				gray = 0
+			}
+
+			img.SetGray(x, y, color.Gray{Y: gray})
+			costs[y][x] = grid.GrayCost(gray)
+		}
+	}
+
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	pngGraph, err := grid.NewGridFromPNG(&buf, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = grid.NewGridFromPNG(bytes.NewReader([]byte("not png")), 10, nil)
+	assert.Error(t, err)
+
+	costsGraph := grid.NewGridFromCosts(costs, 10)
+	pathfinder := NewPathfinder[geom.Vector2]([]graphs.NavGraph[geom.Vector2]{pngGraph, costsGraph})
+	if err := pathfinder.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, pngGraph.VisibleSquares(), 56)
+	assert.False(t, pngGraph.ContainsPoint(geom.Vector2{X: 55, Y: 35}))
+	assert.True(t, pngGraph.ContainsPoint(geom.Vector2{X: 55, Y: 55}))
+	assert.True(t, pngGraph.IsRaycastHit(start, dest))
+	assert.False(t, pngGraph.IsRaycastHit(geom.Vector2{X: 15, Y: 55}, geom.Vector2{X: 85, Y: 55}))
+	assert.False(t, pngGraph.IsRaycastHit(geom.Vector2{X: 15, Y: 5}, geom.Vector2{X: 85, Y: 5}))
+
+	// mud costs 5 times more than road, so road is cheaper than gap under the wall
+	assert.InDelta(t, 5*10, pngGraph.Cost(geom.Vector2{X: 10, Y: 20}, geom.Vector2{X: 20, Y: 20}), 1e-3)
+	assert.InDelta(t, 10, pngGraph.Cost(geom.Vector2{X: 10, Y: 10}, geom.Vector2{X: 20, Y: 10}), 1e-3)
+
+	var paths []Path[geom.Vector2]
+	for graphID := range 2 {
+		path, err := pathfinder.FindPath(ctx, graphID, start, dest)
+		assert.NoError(t, err)
+
+		for _, node := range path.Nodes {
+			if node.X > 30 && node.X < 70 {
+				assert.LessOrEqual(t, node.Y, float32(10))
+			}
+		}
+
+		paths = append(paths, path)
+	}
+
+	assert.InDelta(t, paths[0].Length, paths[1].Length, 1e-3)
+}
+
+func TestPathfinder_GridConnectivity(t *testing.T) {
+	var (
+		// two walls touch each other by corner (20, 20)
+		costs = [][]float32{
+			{1, 1, 1, 1},
+			{1, 0, 1, 1},
+			{1, 1, 0, 1},
+			{1, 1, 1, 1},
+		}
+		start = geom.Vector2{X: 15, Y: 25}
+		dest  = geom.Vector2{X: 25, Y: 15}
+		ctx   = context.Background()
+	)
+
+	navGraphs := []graphs.NavGraph[geom.Vector2]{
+		grid.NewGridFromCosts(costs, 10),
+		grid.NewGridFromCosts(costs, 10, grid.WithConnectivity(grid.Connectivity4)),
+		grid.NewGridFromCosts(costs, 10, grid.WithConnectivity(grid.Connectivity8NoCornerCutting)),
+	}
+	pathfinder := NewPathfinder[geom.Vector2](navGraphs)
+	if err := pathfinder.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	paths := make([]Path[geom.Vector2], len(navGraphs))
+	for graphID := range navGraphs {
+		path, err := pathfinder.FindPath(ctx, graphID, start, dest)
+		assert.NoError(t, err)
+		paths[graphID] = path
+	}
+
+	// path slips between walls only if corners are cut
+	assert.Contains(t, paths[0].Nodes, geom.Vector2{X: 20, Y: 20})
+	assert.InDelta(t, 2*math.Sqrt(50), paths[0].Length, 1e-3)
+	for graphID := 1; graphID < len(navGraphs); graphID++ {
+		assert.NotContains(t, paths[graphID].Nodes, geom.Vector2{X: 20, Y: 20})
+		assert.Greater(t, paths[graphID].Length, paths[0].Length)
+	}
+
+	// sides only
+	for i := 1; i < len(paths[1].Nodes)-2; i++ {
+		a, b := paths[1].Nodes[i], paths[1].Nodes[i+1]
+		assert.True(t, a.X == b.X || a.Y == b.Y)
+	}
+
+	path, err := pathfinder.FindPath(ctx, 2, start, dest, WithJumpPointSearch())
+	assert.NoError(t, err)
+	assert.InDelta(t, paths[2].Length, path.Length, 1e-3)
+
+	// octile and manhattan estimates are decreased by slack of links with corners
+	assert.InDelta(t, 30+10*(math.Sqrt2-1)-0.899, navGraphs[0].(*grid.Grid).Estimate(geom.Vector2{}, geom.Vector2{X: 30, Y: 10}), 1e-3)
+	assert.InDelta(t, 40-10*(2-math.Sqrt2), navGraphs[1].(*grid.Grid).Estimate(geom.Vector2{}, geom.Vector2{X: 30, Y: 10}), 1e-3)
+}
+
+func TestPathfinder_Hex(t *testing.T) {
+	var (
+		room  = []geom.Vector2{{X: 0, Y: 0}, {X: 120, Y: 0}, {X: 120, Y: 100}, {X: 0, Y: 100}}
+		wall  = []geom.Vector2{{X: 50, Y: -10}, {X: 70, Y: -10}, {X: 70, Y: 70}, {X: 50, Y: 70}}
+		gate  = []geom.Vector2{{X: 50, Y: 60}, {X: 70, Y: 60}, {X: 70, Y: 110}, {X: 50, Y: 110}}
+		start = geom.Vector2{X: 15, Y: 40}
+		dest  = geom.Vector2{X: 105, Y: 40}
+		ctx   = context.Background()
+	)
+
+	navGraphs := []graphs.NavGraph[geom.Vector2]{
+		hex.NewGrid(room, [][]geom.Vector2{wall}, 4),
+		hex.NewGrid(room, [][]geom.Vector2{wall}, 4, hex.WithOrientation(hex.Flat)),
+	}
+	pathfinder := NewPathfinder[geom.Vector2](navGraphs)
+	if err := pathfinder.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for graphID, navGraph := range navGraphs {
+		g := navGraph.(*hex.Grid)
+
+		// path goes around the wall through centers of hexes
+		path := mustFindPath(t, pathfinder, graphID, start, dest)
+		assert.Greater(t, path.Length, float32(90+20))
+		for _, node := range path.Nodes[1 : len(path.Nodes)-1] {
+			assert.False(t, node.X > 50 && node.X < 70 && node.Y < 70, node)
+			assert.InDelta(t, 0, geom.Distance(node, g.Center(g.AxialOf(node))), 1e-3)
+		}
+
+		// hex distance heuristic doesn't change the shortest path
+		shortest := mustFindPath(t, pathfinder, graphID, start, dest, WithSearcher(Dijkstra[geom.Vector2]()))
+		assert.InDelta(t, shortest.Length, path.Length, 1e-3)
+		assert.LessOrEqual(t, g.Estimate(start, dest), path.Length)
+
+		ids := g.AddObstacles(mesh.NewObstacle(gate, 0, false))
+		_, err := pathfinder.FindPath(ctx, graphID, start, dest)
+		assert.ErrorIs(t, err, ErrDestUnreachable)
+
+		g.RemoveObstacles(ids...)
+		assert.InDelta(t, path.Length, mustFindPath(t, pathfinder, graphID, start, dest).Length, 1e-3)
+
+		// point out of polygon is moved to the closest hex center
+		closest, ok := g.GetClosestPoint(geom.Vector2{X: -20, Y: 50})
+		assert.True(t, ok)
+		assert.True(t, g.ContainsPoint(closest))
+		assert.Less(t, closest.X, float32(10))
+		assert.False(t, g.ContainsPoint(geom.Vector2{X: 60, Y: 30}))
+		assert.True(t, g.IsRaycastHit(start, dest))
+	}
+}
+
+func TestPathfinder_FindPathAreaFilters(t *testing.T) {
+	const (
+		road mesh.AreaType = iota + 1
+		mud
+	)
+
+	var (
+		room  = []geom.Vector2{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 60}, {X: 0, Y: 60}}
+		swamp = mesh.NewArea([]geom.Vector2{{X: 30, Y: 10}, {X: 70, Y: 10}, {X: 70, Y: 60}, {X: 30, Y: 60}}, mud)
+		way   = mesh.NewArea([]geom.Vector2{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 10}, {X: 0, Y: 10}}, road)
+		start = geom.Vector2{X: 15, Y: 35}
+		dest  = geom.Vector2{X: 85, Y: 35}
+		ctx   = context.Background()
+	)
+
+	pathfinder := NewPathfinder[geom.Vector2]([]graphs.NavGraph[geom.Vector2]{
+		recast.NewRecast([]*mesh.Polygon{mesh.NewPolygon(room, nil, nil, 0).AddAreas(way, swamp)}),
+		grid.NewGrid(room, nil, 10, grid.WithAreas(way, swamp)),
+	})
+	if err := pathfinder.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for graphID := range 2 {
+		path, err := pathfinder.FindPath(ctx, graphID, start, dest)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, path.Nodes)
+
+		path, err = pathfinder.FindPath(ctx, graphID, start, dest, WithExcludeAreas(mud))
+		assert.NoError(t, err)
+		assert.Greater(t, path.Length, float32(80))
+		for _, node := range path.Nodes {
+			if node.X > 30 && node.X < 70 {
+				assert.LessOrEqual(t, node.Y, float32(10))
+			}
+		}
+
+		// swamp and road split the room
+		_, err = pathfinder.FindPath(ctx, graphID, start, dest, WithExcludeAreas(mud, road))
+		assert.ErrorIs(t, err, ErrDestUnreachable)
+
+		_, err = pathfinder.FindPath(ctx, graphID, start, dest, WithIncludeAreas(road))
+		assert.ErrorIs(t, err, ErrDestUnreachable)
+
+		path, err = pathfinder.FindPath(ctx, graphID, geom.Vector2{X: 15, Y: 5}, geom.Vector2{X: 85, Y: 5}, WithIncludeAreas(road))
+		assert.NoError(t, err)
+		assert.NotEmpty(t, path.Nodes)
+
+		// cost overrides of query make swamp expensive
+		path, err = pathfinder.FindPath(ctx, graphID, start, dest, WithAreaCosts(mesh.AreaCosts{mud: 5}))
+		assert.NoError(t, err)
+		assert.Greater(t, path.Length, float32(80))
+		assert.Less(t, path.Length, 40+40*float32(5))
+	}
+}
+
+func TestPathfinder_PathGridHierarchy(t *testing.T) {
+	var (
+		room  = []geom.Vector2{{X: 0, Y: 0}, {X: 200, Y: 0}, {X: 200, Y: 200}, {X: 0, Y: 200}}
+		walls = [][]geom.Vector2{
+			{{X: 60, Y: -10}, {X: 65, Y: -10}, {X: 65, Y: 170}, {X: 60, Y: 170}},
+			{{X: 130, Y: 30}, {X: 135, Y: 30}, {X: 135, Y: 210}, {X: 130, Y: 210}},
+			// closed box
+			{{X: 165, Y: 5}, {X: 169, Y: 5}, {X: 169, Y: 35}, {X: 165, Y: 35}},
+			{{X: 191, Y: 5}, {X: 195, Y: 5}, {X: 195, Y: 35}, {X: 191, Y: 35}},
+			{{X: 165, Y: 5}, {X: 195, Y: 5}, {X: 195, Y: 9}, {X: 165, Y: 9}},
+			{{X: 165, Y: 31}, {X: 195, Y: 31}, {X: 195, Y: 35}, {X: 165, Y: 35}},
+		}
+		ctx = context.Background()
+	)
+
+	pathfinder := NewPathfinder[geom.Vector2]([]graphs.NavGraph[geom.Vector2]{
+		grid.NewGrid(room, walls, 2),
+		grid.NewGrid(room, walls, 2, grid.WithHierarchy(8)),
+	})
+	if err := pathfinder.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := pathfinder.FindPath(ctx, 1, geom.Vector2{X: 11, Y: 11}, geom.Vector2{X: 180, Y: 20})
+	assert.ErrorIs(t, err, ErrDestUnreachable)
+
+	// seeded random rooms, hierarchical path is compared with flat A* path
+	rnd := rand.New(rand.NewSource(1))
+	for range 5 {
+		walls = walls[:0]
+		for range 25 {
+			x, y := 10+rnd.Float32()*170, 10+rnd.Float32()*170
+			w, h := 3+rnd.Float32()*30, 3+rnd.Float32()*30
+			if rnd.Intn(2) == 0 {
+				w, h = h, w
+			}
+
+			walls = append(walls, []geom.Vector2{{X: x, Y: y}, {X: x + w, Y: y}, {X: x + w, Y: y + h}, {X: x, Y: y + h}})
+		}
+
+		pathfinder = NewPathfinder[geom.Vector2]([]graphs.NavGraph[geom.Vector2]{
+			grid.NewGrid(room, walls, 2),
+			grid.NewGrid(room, walls, 2, grid.WithHierarchy(8)),
+		})
+		if err := pathfinder.Initialize(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		vis := pathfinder.Graph(0)
+		for range 40 {
+			start := geom.Vector2{X: 1 + rnd.Float32()*198, Y: 1 + rnd.Float32()*198}
+			dest := geom.Vector2{X: 1 + rnd.Float32()*198, Y: 1 + rnd.Float32()*198}
+
+			flat, flatErr := pathfinder.FindPath(ctx, 0, start, dest)
+			path, err := pathfinder.FindPath(ctx, 1, start, dest)
+			if !assert.Equal(t, flatErr, err, [2]geom.Vector2{start, dest}) || err != nil {
+				continue
+			}
+
+			assert.Equal(t, start, path.Nodes[0])
+			assert.Equal(t, dest, path.Nodes[len(path.Nodes)-1])
+			assert.LessOrEqual(t, path.Length, flat.Length*1.02+1e-3, [2]geom.Vector2{start, dest})
+			for i := 1; i < len(path.Nodes)-2; i++ {
+				assert.Contains(t, vis[path.Nodes[i]], path.Nodes[i+1])
+			}
+		}
+	}
+}
+
+func TestPathfinder_PathJumpPointSearch(t *testing.T) {
+	var (
+		room  = []geom.Vector2{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 100}, {X: 0, Y: 100}}
+		walls = [][]geom.Vector2{
+			{{X: 30, Y: -10}, {X: 35, Y: -10}, {X: 35, Y: 70}, {X: 30, Y: 70}},
+			{{X: 60, Y: 25}, {X: 80, Y: 40}, {X: 62, Y: 55}},
+			{{X: 45, Y: 80}, {X: 90, Y: 80}, {X: 90, Y: 85}, {X: 45, Y: 85}},
+		}
+		ctx = context.Background()
+	)
+
+	pathfinder := NewPathfinder[geom.Vector2]([]graphs.NavGraph[geom.Vector2]{
+		grid.NewGrid(room, walls, 2),
+		grid.NewGrid(room, walls, 2, grid.WithJumpPointSearch(true)),
+	})
+	if err := pathfinder.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	vis := pathfinder.Graph(0)
+	for _, q := range [][2]geom.Vector2{
+		{{X: 11, Y: 11}, {X: 95, Y: 95}},
+		{{X: 20, Y: 5}, {X: 70, Y: 20}},
+		{{X: 70, Y: 95}, {X: 70, Y: 60}},
+		{{X: 4, Y: 30}, {X: 6, Y: 30}},
+		{{X: 50, Y: 50}, {X: 50, Y: 50}},
+	} {
+		flat, err := pathfinder.FindPath(ctx, 0, q[0], q[1])
+		assert.NoError(t, err)
+
+		for _, path := range []Path[geom.Vector2]{
+			mustFindPath(t, pathfinder, 1, q[0], q[1]),
+			mustFindPath(t, pathfinder, 0, q[0], q[1], WithJumpPointSearch()),
+		} {
+			assert.InDelta(t, flat.Length, path.Length, 1e-3, q)
+			assert.Equal(t, q[0], path.Nodes[0])
+			assert.Equal(t, q[1], path.Nodes[len(path.Nodes)-1])
+			for i := 1; i < len(path.Nodes)-2; i++ {
+				assert.Contains(t, vis[path.Nodes[i]], path.Nodes[i+1])
+			}
+		}
+	}
+
+	_, err := pathfinder.FindPath(ctx, 1, geom.Vector2{X: 11, Y: 11}, geom.Vector2{X: 32, Y: 30})
+	assert.ErrorIs(t, err, ErrDestOutsideMesh)
+}
+
+func TestPathfinder_GridObstacles(t *testing.T) {
+	var (
+		room  = []geom.Vector2{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 100}, {X: 0, Y: 100}}
+		wall  = []geom.Vector2{{X: 46, Y: 20}, {X: 54, Y: 20}, {X: 54, Y: 110}, {X: 46, Y: 110}}
+		gate  = []geom.Vector2{{X: 46, Y: -10}, {X: 54, Y: -10}, {X: 54, Y: 20}, {X: 46, Y: 20}}
+		start = geom.Vector2{X: 12, Y: 52}
+		dest  = geom.Vector2{X: 88, Y: 52}
+		ctx   = context.Background()
+	)
+
+	navGraphs := []graphs.NavGraph[geom.Vector2]{
+		grid.NewGrid(room, nil, 5),
+		grid.NewGrid(room, nil, 5, grid.WithHierarchy(4)),
+		grid.NewGrid(room, nil, 5, grid.WithJumpPointSearch(true)),
+	}
+
+	// obstacle added before Generate blocks squares of generated grid
+	early := navGraphs[0].AddObstacles(mesh.NewObstacle(wall, 0, false))
+	pathfinder := NewPathfinder[geom.Vector2](navGraphs, WithPathCache(8))
+	if err := pathfinder.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	direct := mustFindPath(t, pathfinder, 1, start, dest)
+	detour := mustFindPath(t, pathfinder, 0, start, dest)
+	assert.Greater(t, detour.Length, direct.Length+20)
+
+	for graphID, g := range navGraphs[1:] {
+		ids := g.AddObstacles(mesh.NewObstacle(wall, 0, false), mesh.NewObstacle(gate, 1, false))
+		assert.Len(t, ids, 2)
+		assert.NotEqual(t, ids[0], ids[1])
+
+		_, err := pathfinder.FindPath(ctx, graphID+1, start, dest)
+		assert.ErrorIs(t, err, ErrDestUnreachable)
+
+		// removed gate opens path under the wall
+		g.RemoveObstacles(ids[1])
+		path := mustFindPath(t, pathfinder, graphID+1, start, dest)
+		assert.InDelta(t, detour.Length, path.Length, float64(0.1*detour.Length))
+		for _, node := range path.Nodes {
+			assert.False(t, node.X > 45 && node.X < 55 && node.Y > 20, node)
+		}
+
+		g.RemoveObstacles(ids[0])
+		assert.InDelta(t, direct.Length, mustFindPath(t, pathfinder, graphID+1, start, dest).Length, 1e-3)
+	}
+
+	// jump points and visibility graph find the same path
+	navGraphs[2].AddObstacles(mesh.NewObstacle(wall, 0, false))
+	assert.InDelta(t, detour.Length, mustFindPath(t, pathfinder, 2, start, dest).Length, 1e-3)
+
+	// obstacle far from cached path keeps it, removed obstacle drops it
+	stats := pathfinder.CacheStats()
+	far := navGraphs[0].AddObstacles(mesh.NewObstacle([]geom.Vector2{{X: 80, Y: 5}, {X: 90, Y: 5}, {X: 90, Y: 10}}, 0, false))
+	assert.Equal(t, detour, mustFindPath(t, pathfinder, 0, start, dest))
+	assert.Equal(t, stats.Hits+1, pathfinder.CacheStats().Hits)
+
+	navGraphs[0].RemoveObstacles(append(far, early...)...)
+	assert.InDelta(t, direct.Length, mustFindPath(t, pathfinder, 0, start, dest).Length, 1e-3)
+	assert.Equal(t, stats.Invalidations+1, pathfinder.CacheStats().Invalidations)
+}
+
+func TestPathfinder_GridRaycast(t *testing.T) {
+	var (
+		room   = []geom.Vector2{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 100}, {X: 0, Y: 100}}
+		wall   = []geom.Vector2{{X: 40, Y: 20}, {X: 60, Y: 20}, {X: 60, Y: 100}, {X: 40, Y: 100}}
+		inner  = []geom.Vector2{{X: 41, Y: 21}, {X: 59, Y: 21}, {X: 59, Y: 99}, {X: 41, Y: 99}}
+		pillar = []geom.Vector2{{X: 70, Y: 10}, {X: 80, Y: 10}, {X: 80, Y: 15}, {X: 70, Y: 15}}
+		start  = geom.Vector2{X: 15, Y: 85}
+		dest   = geom.Vector2{X: 85, Y: 75}
+		ctx    = context.Background()
+	)
+
+	gridGraph := grid.NewGrid(room, [][]geom.Vector2{wall}, 5, grid.WithObstacleRaycasts(true))
+	pathfinder := NewPathfinder[geom.Vector2]([]graphs.NavGraph[geom.Vector2]{
+		recast.NewRecast([]*mesh.Polygon{mesh.NewPolygon(room, nil, []*mesh.Hole{mesh.NewObstacle(wall, 0, false)}, 0)}),
+		gridGraph,
+		grid.NewGrid(room, [][]geom.Vector2{inner}, 5),
+	})
+	if err := pathfinder.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// grid raycasts match recast ones
+	for _, segment := range [][2]geom.Vector2{
+		{start, dest},
+		{{X: 10, Y: 10}, {X: 90, Y: 10}},
+		{{X: 10, Y: 10}, {X: 50, Y: 50}},
+		{{X: 30, Y: 20}, {X: 70, Y: 19}},
+		{{X: 35, Y: 15}, {X: 45, Y: 25}},
+		{{X: 50, Y: 50}, {X: 55, Y: 55}},
+		{{X: 50, Y: 50}, {X: 150, Y: 50}},
+		{{X: 110, Y: 10}, {X: 150, Y: 90}},
+		{{X: 5, Y: 5}, {X: 5, Y: 5}},
+	} {
+		assert.Equal(t, pathfinder.IsRaycastHit(0, segment[0], segment[1]), pathfinder.IsRaycastHit(1, segment[0], segment[1]), segment)
+	}
+
+	assert.True(t, pathfinder.IsRaycastHit(1, start, dest))
+	assert.False(t, pathfinder.IsRaycastHit(1, geom.Vector2{X: 10, Y: 10}, geom.Vector2{X: 90, Y: 10}))
+
+	// extra obstacles block raycasts if they aren't viewable
+	ids := gridGraph.AddObstacles(mesh.NewObstacle(pillar, 0, false))
+	assert.True(t, pathfinder.IsRaycastHit(1, geom.Vector2{X: 10, Y: 12}, geom.Vector2{X: 90, Y: 12}))
+	gridGraph.RemoveObstacles(ids...)
+	gridGraph.AddObstacles(mesh.NewObstacle(pillar, 0, true))
+	assert.False(t, pathfinder.IsRaycastHit(1, geom.Vector2{X: 10, Y: 12}, geom.Vector2{X: 90, Y: 12}))
+
+	// any-angle path doesn't go through the wall, segments which touch its outline don't cross inner part of it
+	flat := mustFindPath(t, pathfinder, 1, start, dest)
+	path := mustFindPath(t, pathfinder, 1, start, dest, WithSearcher(ThetaStar[geom.Vector2]()))
+	assert.Less(t, path.Length, flat.Length)
+	assert.Greater(t, path.Length, geom.Distance(start, dest)+50)
+	for i := 0; i < len(path.Nodes)-1; i++ {
+		assert.False(t, pathfinder.IsRaycastHit(2, path.Nodes[i], path.Nodes[i+1]), path.Nodes[i:i+2])
+	}
+}
+
+func TestPathfinder_PathSearchers(t *testing.T) {
+	var (
+		room  = []geom.Vector2{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 100}, {X: 0, Y: 100}}
+		wall  = []geom.Vector2{{X: 40, Y: 20}, {X: 60, Y: 20}, {X: 60, Y: 100}, {X: 40, Y: 100}}
+		start = geom.Vector2{X: 15, Y: 85}
+		dest  = geom.Vector2{X: 85, Y: 75}
+		ctx   = context.Background()
+	)
+
+	pathfinder := NewPathfinder[geom.Vector2]([]graphs.NavGraph[geom.Vector2]{
+		recast.NewRecast([]*mesh.Polygon{mesh.NewPolygon(room, nil, []*mesh.Hole{mesh.NewObstacle(wall, 0, false)}, 0)}),
+		grid.NewGrid(room, [][]geom.Vector2{wall}, 2, grid.WithJumpPointSearch(true)),
+	})
+	if err := pathfinder.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for graphID := range pathfinder.GraphsNum() {
+		shortest := mustFindPath(t, pathfinder, graphID, start, dest, WithSearcher(AStar[geom.Vector2]()))
+		assertPathCosts(t, shortest)
+
+		for _, searcher := range []Searcher[geom.Vector2]{Dijkstra[geom.Vector2](), BidirectionalAStar[geom.Vector2]()} {
+			path := mustFindPath(t, pathfinder, graphID, start, dest, WithSearcher(searcher))
+			assert.InDelta(t, shortest.Length, path.Length, 1e-3)
+			assert.Equal(t, start, path.Nodes[0])
+			assert.Equal(t, dest, path.Nodes[len(path.Nodes)-1])
+		}
+
+		weighted := mustFindPath(t, pathfinder, graphID, start, dest, WithSearcher(WeightedAStar[geom.Vector2](2)))
+		assert.GreaterOrEqual(t, weighted.Length, shortest.Length-1e-3)
+		assert.LessOrEqual(t, weighted.Length, 2*shortest.Length)
+	}
+
+	// any-angle path isn't longer than path over graph edges
+	shortest := mustFindPath(t, pathfinder, 0, start, dest)
+	path := mustFindPath(t, pathfinder, 0, start, dest, WithSearcher(ThetaStar[geom.Vector2]()))
+	assert.LessOrEqual(t, path.Length, shortest.Length+1e-3)
+	assert.LessOrEqual(t, len(path.Nodes), len(shortest.Nodes))
+	assert.Equal(t, dest, path.Nodes[len(path.Nodes)-1])
+
+	// own searcher gets query of aggregation graph
+	counter := &countingSearcher{Searcher: AStar[geom.Vector2]()}
+	path = mustFindPath(t, pathfinder, 1, start, dest, WithSearcher[geom.Vector2](counter))
+	assert.Equal(t, 1, counter.calls)
+	assert.InDelta(t, mustFindPath(t, pathfinder, 1, start, dest).Length, path.Length, 1e-3)
+
+	// searcher of another node type is ignored
+	assert.Equal(t, shortest, mustFindPath(t, pathfinder, 0, start, dest, WithSearcher(AStar[int]())))
+
+	_, err := pathfinder.FindPath(ctx, 1, start, geom.Vector2{X: 50, Y: 50}, WithSearcher(BidirectionalAStar[geom.Vector2]()))
+	assert.ErrorIs(t, err, ErrDestOutsideMesh)
+}
+
+func TestPathfinder_ThetaStarWalkable(t *testing.T) {
+	var (
+		room = []geom.Vector2{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 100}, {X: 0, Y: 100}}
+		hole = []geom.Vector2{{X: 40, Y: 10}, {X: 60, Y: 10}, {X: 60, Y: 90}, {X: 40, Y: 90}}
+		// inner hole is wound opposite to polygon
+		inner = []geom.Vector2{{X: 40, Y: 90}, {X: 60, Y: 90}, {X: 60, Y: 10}, {X: 40, Y: 10}}
+		start = geom.Vector2{X: 20, Y: 50}
+		dest  = geom.Vector2{X: 80, Y: 50}
+		ctx   = context.Background()
+	)
+
+	// distance from point to rectangle, negative inside
+	rectDistance := func(point geom.Vector2, lo, hi geom.Vector2) float32 {
+		dx := max(lo.X-point.X, point.X-hi.X)
+		dy := max(lo.Y-point.Y, point.Y-hi.Y)
+		if dx < 0 && dy < 0 {
+			return max(dx, dy)
+		}
+
+		return float32(math.Hypot(float64(max(dx, 0)), float64(max(dy, 0))))
+	}
+
+	// segments of path keep distance to rectangle
+	assertClear := func(path Path[geom.Vector2], lo, hi geom.Vector2, distance float32) {
+		for i := 0; i < len(path.Nodes)-1; i++ {
+			for step := float32(0); step <= 1; step += 0.01 {
+				point := path.Nodes[i].Lerp(path.Nodes[i+1], step)
+				assert.GreaterOrEqual(t, rectDistance(point, lo, hi), distance-1e-2, path.Nodes)
+			}
+		}
+	}
+
+	pathfinder := NewPathfinder[geom.Vector2]([]graphs.NavGraph[geom.Vector2]{
+		recast.NewRecast([]*mesh.Polygon{mesh.NewPolygon(room, []*mesh.Hole{mesh.NewInnerHole(inner, 0)}, nil, 0)}),
+		recast.NewRecast([]*mesh.Polygon{mesh.NewPolygon(room, nil, []*mesh.Hole{mesh.NewObstacle(hole, 0, true)}, 0)}),
+		recast.NewRecast([]*mesh.Polygon{mesh.NewPolygon(room, nil, nil, 0)}),
+		grid.NewGrid(room, nil, 5),
+		hex.NewGrid(room, nil, 3),
+	})
+	if err := pathfinder.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, graphID := range []int{3, 4} {
+		pathfinder.graphs[graphID].AddObstacles(mesh.NewObstacle(hole, 0, true))
+	}
+
+	// any-angle path goes around inner hole and obstacles which raycasts don't hit
+	for _, graphID := range []int{0, 1, 3, 4} {
+		shortest := mustFindPath(t, pathfinder, graphID, start, dest)
+		path := mustFindPath(t, pathfinder, graphID, start, dest, WithSearcher(ThetaStar[geom.Vector2]()))
+		assert.Greater(t, path.Length, float32(100))
+		assert.LessOrEqual(t, path.Length, shortest.Length+1e-3)
+		assertClear(path, hole[0], hole[2], 0)
+	}
+
+	// obstacles of query are inflated by agent radius
+	var (
+		wall = obstacles.GenerateRectangle(geom.Vector2{X: 50, Y: 50}, 20, 80)
+		opts = []PathOption{WithObstacles([]obstacles.Obstacle{wall}), WithAgentRadius(3), WithSearcher(ThetaStar[geom.Vector2]())}
+	)
+
+	path := mustFindPath(t, pathfinder, 2, start, dest, opts...)
+	assert.Greater(t, path.Length, float32(100))
+	assertClear(path, hole[0], hole[2], 3)
+}
+
+type countingSearcher struct {
+	Searcher[geom.Vector2]
+	calls int
+}
+
+func (s *countingSearcher) Search(q *SearchQuery[geom.Vector2]) []geom.Vector2 {
+	s.calls++
+	return s.Searcher.Search(q)
+}
+
+func TestPathfinder_BeginPath(t *testing.T) {
+	polygon, holes, _, err := utils.NewPolygonsFromJSON([]byte(floorPlan))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		roomA = []geom.Vector2{{X: 600, Y: 0}, {X: 700, Y: 0}, {X: 700, Y: 100}, {X: 600, Y: 100}}
+		start = geom.Vector2{X: 60, Y: 10}
+		dest  = geom.Vector2{X: 240, Y: 700}
+		ctx   = context.Background()
+	)
+
+	nHoles := make([]*mesh.Hole, len(holes))
+	for i, hole := range holes {
+		nHoles[i] = mesh.NewObstacle(hole, 0, false)
+	}
+
+	pathfinder := NewPathfinder[geom.Vector2]([]graphs.NavGraph[geom.Vector2]{
+		recast.NewRecast([]*mesh.Polygon{mesh.NewPolygon(polygon, nil, nHoles, 0), mesh.NewPolygon(roomA, nil, nil, 0)}),
+	})
+
+	_, err = pathfinder.BeginPath(0, start, dest)
+	assert.ErrorIs(t, err, ErrNotInitialized)
+
+	if err = pathfinder.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = pathfinder.BeginPath(1, start, dest)
+	assert.ErrorIs(t, err, ErrUnknownGraph)
+
+	query, err := pathfinder.BeginPath(0, start, dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = query.Result()
+	assert.ErrorIs(t, err, ErrPathInProgress)
+
+	steps := 1
+	for query.Step(2) == PathInProgress {
+		steps++
+	}
+
+	assert.Greater(t, steps, 1)
+	assert.Equal(t, PathFound, query.Status())
+	assert.Equal(t, PathFound, query.Step(2))
+
+	path, err := query.Result()
+	assert.NoError(t, err)
+	assert.Equal(t, mustFindPath(t, pathfinder, 0, start, dest), path)
+
+	// visible points are linked at once
+	query, err = pathfinder.BeginPath(0, start, geom.Vector2{X: 100, Y: 100})
+	assert.NoError(t, err)
+	assert.Equal(t, PathFound, query.Status())
+
+	// searcher of options and graph search modes find the same path as FindPath at once
+	triangles := NewPathfinder[geom.Vector2]([]graphs.NavGraph[geom.Vector2]{
+		recast.NewRecast([]*mesh.Polygon{mesh.NewPolygon(polygon, nil, nHoles, 0)}, recast.WithTriangleSearch(true)),
+	})
+	if err = triangles.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	modes := []struct {
+		pathfinder *Pathfinder[geom.Vector2]
+		opts       []PathOption
+	}{
+		{pathfinder: pathfinder, opts: []PathOption{WithSearcher(ThetaStar[geom.Vector2]())}},
+		{pathfinder: triangles},
+	}
+
+	for _, mode := range modes {
+		query, err = mode.pathfinder.BeginPath(0, start, dest, mode.opts...)
+		assert.NoError(t, err)
+		assert.Equal(t, PathFound, query.Status())
+
+		path, err = query.Result()
+		assert.NoError(t, err)
+		assert.Equal(t, mustFindPath(t, mode.pathfinder, 0, start, dest, mode.opts...), path)
+	}
+
+	tests := []struct {
+		name    string
+		dest    geom.Vector2
+		wantErr error
+	}{
+		{name: "dest outside", dest: geom.Vector2{X: 550, Y: 50}, wantErr: ErrDestOutsideMesh},
+		{name: "unreachable", dest: geom.Vector2{X: 650, Y: 50}, wantErr: ErrDestUnreachable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := pathfinder.BeginPath(0, start, tt.dest)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, PathFailed, query.Step(0))
+
+			path, err := query.Result()
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Empty(t, path.Nodes)
+		})
+	}
+}
+
+func mustFindPath(t *testing.T, pathfinder *Pathfinder[geom.Vector2], graphID int, start, dest geom.Vector2, opts ...PathOption) Path[geom.Vector2] {
+	t.Helper()
+
+	path, err := pathfinder.FindPath(context.Background(), graphID, start, dest, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func assertPathCosts(t *testing.T, path Path[geom.Vector2]) {
+	t.Helper()
+
+	assert.Len(t, path.Costs, len(path.Nodes)-1)
+
+	length := float32(0)
+	for i := 0; i < len(path.Nodes)-1; i++ {
+		assert.InDelta(t, geom.Distance(path.Nodes[i], path.Nodes[i+1]), path.Costs[i], 1e-3)
+		length += path.Costs[i]
+	}
+
+	assert.InDelta(t, length, path.Length, 1e-3)
+	// path goes around the wall
+	assert.Greater(t, path.Length, geom.Distance(path.Nodes[0], path.Nodes[len(path.Nodes)-1]))
+}
+
+func TestPathfinder_InitializeCanceled(t *testing.T) {
+	room := []geom.Vector2{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 100}, {X: 0, Y: 100}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	progress := make([]float32, 0)
+	pathfinder := NewPathfinder[geom.Vector2]([]graphs.NavGraph[geom.Vector2]{
+		grid.NewGrid(room, nil, 5, grid.WithProgress(func(p float32) {
+			progress = append(progress, p)
+			if p > 0.5 {
+				cancel()
+			}
+		})),
+	})
+
+	assert.ErrorIs(t, pathfinder.Initialize(ctx), context.Canceled)
+	assert.NotEmpty(t, progress)
+	assert.Less(t, progress[len(progress)-1], float32(1))
+
+	_, err := pathfinder.FindPath(context.Background(), 0, geom.Vector2{X: 10, Y: 10}, geom.Vector2{X: 90, Y: 90})
+	assert.ErrorIs(t, err, ErrNotInitialized)
+
+	progress = progress[:0]
+	assert.NoError(t, NewPathfinder[geom.Vector2]([]graphs.NavGraph[geom.Vector2]{
+		grid.NewGrid(room, nil, 5, grid.WithProgress(func(p float32) {
+			progress = append(progress, p)
+		})),
+	}).Initialize(context.Background()))
+	assert.Equal(t, float32(1), progress[len(progress)-1])
+}
+
+// isPathIntersectsRect checks if any path segment crosses axis-aligned rectangle
+func isPathIntersectsRect(path []geom.Vector2, minX, minY, maxX, maxY float32) bool {
+	for i := 0; i < len(path)-1; i++ {
+		a, b := path[i], path[i+1]
+		for s := float32(0); s <= 1; s += 0.01 {
+			p := a.Lerp(b, s)
+			if p.X > minX && p.X < maxX && p.Y > minY && p.Y < maxY {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,126 @@
+package pathfind
+
+import (
+	"github.com/bolom009/astar"
+	"github.com/bolom009/pathfind/graphs"
+)
+
+// PathStatus represent state of time-sliced path query
+type PathStatus uint8
+
+const (
+	// PathInProgress is status of query which search isn't finished
+	PathInProgress PathStatus = iota
+	// PathFound is status of query which found path
+	PathFound
+	// PathFailed is status of query which search is finished without path
+	PathFailed
+)
+
+// PathQuery is time-sliced path search created by BeginPath, each Step expands limited count of nodes,
+// so long search could be spread over several frames. Query keeps aggregation graph and open list between steps,
+// graph updates which are done after BeginPath don't affect it. Query isn't safe for concurrent use
+type PathQuery[Node comparable] struct {
+	p           *Pathfinder[Node]
+	g           graphs.NavGraph[Node]
+	navOpts     *graphs.NavOpts
+	start, dest Node
+	vis         graphs.Graph[Node]
+	cost        astar.CostFunc[Node]
+	heuristic   astar.CostFunc[Node]
+	s           *scratch[Node]
+	status      PathStatus
+	nodes       []Node
+}
+
+// BeginPath starts time-sliced search of path from start to dest, path is searched by A* over aggregation graph
+// like by Path. Searcher of options and graph search modes (triangle search, JPS, hierarchy) aren't time-sliced,
+// their path is found at once like direct path of visible points. Query doesn't use path cache.
+// ErrNotInitialized or ErrUnknownGraph is returned if search can't be started
+func (p *Pathfinder[Node]) BeginPath(graphID int, start, dest Node, opts ...PathOption) (*PathQuery[Node], error) {
+	if !p.initialized.Load() {
+		return nil, ErrNotInitialized
+	}
+
+	g, err := p.graph(graphID)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &PathQuery[Node]{
+		p:       p,
+		g:       g,
+		navOpts: newNavOpts(opts),
+		start:   start,
+		dest:    dest,
+	}
+
+	if _, ok := q.navOpts.Searcher.(Searcher[Node]); ok {
+		q.s = p.scratches.Get().(*scratch[Node])
+		q.finish(p.searchGraph(g, start, dest, q.navOpts, q.s))
+		return q, nil
+	}
+
+	if pathSearcher, ok := g.(graphs.PathSearcher[Node]); ok {
+		if path, ok := pathSearcher.SearchPath(start, dest, q.navOpts); ok {
+			q.finish(path)
+			return q, nil
+		}
+	}
+
+	q.vis = g.AggregationGraph(start, dest, q.navOpts)
+	if len(q.vis) == 2 && q.vis[start][0] == dest && q.vis[dest][0] == start {
+		q.finish([]Node{start, dest})
+		return q, nil
+	}
+
+	q.cost, q.heuristic = costFuncs(g, q.navOpts)
+	q.s = p.scratches.Get().(*scratch[Node])
+	q.s.begin(start, dest, g.HashIndex, q.heuristic)
+
+	return q, nil
+}
+
+// Step expands up to maxIterations nodes (all nodes if maxIterations isn't positive) and return status of query
+func (q *PathQuery[Node]) Step(maxIterations int) PathStatus {
+	if q.status != PathInProgress {
+		return q.status
+	}
+
+	if nodes, done := q.s.step(q.vis, q.start, q.dest, q.g.HashIndex, q.cost, q.heuristic, nil, maxIterations); done {
+		q.finish(nodes)
+	}
+
+	return q.status
+}
+
+// Status return status of query
+func (q *PathQuery[Node]) Status() PathStatus {
+	return q.status
+}
+
+// Result return found path like FindPath, ErrPathInProgress is returned until search is finished
+func (q *PathQuery[Node]) Result() (Path[Node], error) {
+	switch q.status {
+	case PathInProgress:
+		return Path[Node]{}, ErrPathInProgress
+	case PathFailed:
+		return Path[Node]{}, notFoundError(q.g, q.start, q.dest)
+	}
+
+	return newPath(q.g, q.nodes, q.navOpts), nil
+}
+
+// finish set result of search, scratch buffers are returned to pathfinder and graph of query is released
+func (q *PathQuery[Node]) finish(nodes []Node) {
+	q.status, q.nodes = PathFailed, nodes
+	if len(nodes) > 0 {
+		q.status = PathFound
+	}
+
+	if q.s != nil {
+		q.p.scratches.Put(q.s)
+	}
+
+	q.s, q.vis = nil, nil
+}
@@ -0,0 +1,232 @@
+package pathfind
+
+import (
+	"math"
+	"slices"
+
+	"github.com/bolom009/astar"
+)
+
+// predictedCapacity is initial capacity of scratch buffers
+const predictedCapacity = 64
+
+// scratch is reusable memory of A* search (open list, scores and came-from map)
+// it's used by one search at a time, buffers keep their capacity between searches
+type scratch[Node comparable] struct {
+	open     []queueItem[Node]
+	gScore   map[int64]float32
+	cameFrom map[Node]Node
+	// backward is memory of backward search of bidirectional A*, it's created on first use
+	backward *scratch[Node]
+}
+
+type queueItem[Node comparable] struct {
+	node     Node
+	priority float32
+}
+
+func newScratch[Node comparable]() *scratch[Node] {
+	return &scratch[Node]{
+		open:     make([]queueItem[Node], 0, predictedCapacity),
+		gScore:   make(map[int64]float32, predictedCapacity),
+		cameFrom: make(map[Node]Node, predictedCapacity),
+	}
+}
+
+func (s *scratch[Node]) reset() {
+	s.open = s.open[:0]
+	clear(s.gScore)
+	clear(s.cameFrom)
+}
+
+// findPath finds the least-cost path from start to dest by A* search, d is cost of edge and h is heuristic
+// if los is set, neighbour is linked with predecessor of current node which it sees (Theta*)
+// The function returns nil if no path exists
+func (s *scratch[Node]) findPath(g astar.Graph[Node], start, dest Node, hashFn astar.HasherFunc[Node], d, h astar.CostFunc[Node], los func(a, b Node) bool) []Node {
+	s.begin(start, dest, hashFn, h)
+	path, _ := s.step(g, start, dest, hashFn, d, h, los, 0)
+
+	return path
+}
+
+// begin reset buffers and put start to open list
+func (s *scratch[Node]) begin(start, dest Node, hashFn astar.HasherFunc[Node], h astar.CostFunc[Node]) {
+	s.reset()
+	s.push(queueItem[Node]{node: start, priority: h(start, dest)})
+	s.gScore[hashFn(start)] = 0
+}
+
+// step expands up to maxIterations nodes of open list (all nodes if maxIterations isn't positive)
+// it returns path and true if search is finished, path is nil if no path exists
+func (s *scratch[Node]) step(g astar.Graph[Node], start, dest Node, hashFn astar.HasherFunc[Node], d, h astar.CostFunc[Node], los func(a, b Node) bool, maxIterations int) ([]Node, bool) {
+	for i := 0; len(s.open) > 0; i++ {
+		if maxIterations > 0 && i >= maxIterations {
+			return nil, false
+		}
+
+		current := s.pop().node
+		if current == dest {
+			return s.reconstructPath(current, start), true
+		}
+
+		curScore := s.gScore[hashFn(current)]
+		for _, nb := range g.Neighbours(current) {
+			var (
+				nbk  = hashFn(nb)
+				from = current
+				tent = curScore + d(current, nb)
+			)
+
+			if parent, ok := s.cameFrom[current]; ok && los != nil && current != start && los(parent, nb) {
+				from, tent = parent, s.gScore[hashFn(parent)]+d(parent, nb)
+			}
+
+			if gs, ok := s.gScore[nbk]; !ok || tent < gs {
+				s.gScore[nbk] = tent
+				s.cameFrom[nb] = from
+				s.push(queueItem[Node]{node: nb, priority: tent + h(nb, dest)})
+			}
+		}
+	}
+
+	return nil, true
+}
+
+// findPathBidirectional finds path by A* searches from start and from dest, search with the lower priority is expanded.
+// Searches stop when one of them can't find cheaper path than the best met one, backward search goes
+// by edges which are linked both ways
+func (s *scratch[Node]) findPathBidirectional(g astar.Graph[Node], start, dest Node, hashFn astar.HasherFunc[Node], d, h astar.CostFunc[Node]) []Node {
+	if s.backward == nil {
+		s.backward = newScratch[Node]()
+	}
+
+	var (
+		b     = s.backward
+		meet  Node
+		best  = float32(math.MaxFloat32)
+		found = false
+	)
+
+	s.reset()
+	b.reset()
+	s.push(queueItem[Node]{node: start, priority: h(start, dest)})
+	s.gScore[hashFn(start)] = 0
+	b.push(queueItem[Node]{node: dest, priority: h(dest, start)})
+	b.gScore[hashFn(dest)] = 0
+	if start == dest {
+		return []Node{start}
+	}
+
+	for len(s.open) > 0 {
+		if found && (s.open[0].priority >= best || (len(b.open) > 0 && b.open[0].priority >= best)) {
+			break
+		}
+
+		if len(b.open) > 0 && b.open[0].priority < s.open[0].priority {
+			current := b.pop().node
+			curScore := b.gScore[hashFn(current)]
+			for _, nb := range g.Neighbours(current) {
+				if !slices.Contains(g.Neighbours(nb), current) {
+					continue
+				}
+
+				nbk := hashFn(nb)
+				tent := curScore + d(nb, current)
+				if gs, ok := b.gScore[nbk]; !ok || tent < gs {
+					b.gScore[nbk] = tent
+					b.cameFrom[nb] = current
+					b.push(queueItem[Node]{node: nb, priority: tent + h(nb, start)})
+					if fs, ok := s.gScore[nbk]; ok && fs+tent < best {
+						meet, best, found = nb, fs+tent, true
+					}
+				}
+			}
+
+			continue
+		}
+
+		current := s.pop().node
+		curScore := s.gScore[hashFn(current)]
+		for _, nb := range g.Neighbours(current) {
+			nbk := hashFn(nb)
+			tent := curScore + d(current, nb)
+			if gs, ok := s.gScore[nbk]; !ok || tent < gs {
+				s.gScore[nbk] = tent
+				s.cameFrom[nb] = current
+				s.push(queueItem[Node]{node: nb, priority: tent + h(nb, dest)})
+				if bs, ok := b.gScore[nbk]; ok && tent+bs < best {
+					meet, best, found = nb, tent+bs, true
+				}
+			}
+		}
+	}
+
+	if !found {
+		return nil
+	}
+
+	path := s.reconstructPath(meet, start)
+	for node := meet; node != dest; {
+		node = b.cameFrom[node]
+		path = append(path, node)
+	}
+
+	return path
+}
+
+func (s *scratch[Node]) reconstructPath(current, start Node) []Node {
+	path := make([]Node, 0, 20)
+	for current != start {
+		path = append(path, current)
+		current = s.cameFrom[current]
+	}
+
+	path = append(path, start)
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	return path
+}
+
+// push add item to binary heap of open list
+func (s *scratch[Node]) push(item queueItem[Node]) {
+	s.open = append(s.open, item)
+	for i := len(s.open) - 1; i > 0; {
+		parent := (i - 1) / 2
+		if s.open[parent].priority <= s.open[i].priority {
+			break
+		}
+
+		s.open[parent], s.open[i] = s.open[i], s.open[parent]
+		i = parent
+	}
+}
+
+// pop remove item with the lowest priority from binary heap of open list
+func (s *scratch[Node]) pop() queueItem[Node] {
+	var (
+		top  = s.open[0]
+		last = len(s.open) - 1
+	)
+
+	s.open[0] = s.open[last]
+	s.open = s.open[:last]
+	for i := 0; ; {
+		smallest, left, right := i, 2*i+1, 2*i+2
+		if left < last && s.open[left].priority < s.open[smallest].priority {
+			smallest = left
+		}
+		if right < last && s.open[right].priority < s.open[smallest].priority {
+			smallest = right
+		}
+		if smallest == i {
+			break
+		}
+
+		s.open[i], s.open[smallest] = s.open[smallest], s.open[i]
+		i = smallest
+	}
+
+	return top
+}
@@ -0,0 +1,106 @@
+package pathfind
+
+import (
+	"github.com/bolom009/astar"
+)
+
+// Searcher represent algorithm which finds path over aggregation graph of query
+// Built-in searchers are AStar, WeightedAStar, BidirectionalAStar, Dijkstra and ThetaStar.
+// Search returns nil if no path exists
+type Searcher[Node comparable] interface {
+	Search(q *SearchQuery[Node]) []Node
+}
+
+// SearchQuery contains aggregation graph of query and graph functions which are used by search
+// Cost and Heuristic take area costs of query into account, Hash is NavGraph.HashIndex,
+// LineOfSight is graphs.SightChecker check of query or negated NavGraph.IsRaycastHit if graph doesn't implement it
+type SearchQuery[Node comparable] struct {
+	Graph       astar.Graph[Node]
+	Start       Node
+	Dest        Node
+	Cost        func(a, b Node) float32
+	Heuristic   func(a, b Node) float32
+	Hash        func(Node) int64
+	LineOfSight func(a, b Node) bool
+
+	// scratch is reusable memory of built-in searchers
+	scratch *scratch[Node]
+}
+
+func (q *SearchQuery[Node]) buffers() *scratch[Node] {
+	if q.scratch == nil {
+		q.scratch = newScratch[Node]()
+	}
+
+	return q.scratch
+}
+
+type aStar[Node comparable] struct{}
+
+type weightedAStar[Node comparable] struct {
+	weight float32
+}
+
+type bidirectionalAStar[Node comparable] struct{}
+
+type dijkstra[Node comparable] struct{}
+
+type thetaStar[Node comparable] struct{}
+
+// AStar return searcher of the shortest path, it's used by default
+func AStar[Node comparable]() Searcher[Node] {
+	return aStar[Node]{}
+}
+
+// WeightedAStar return searcher which multiplies heuristic by weight, it expands fewer nodes
+// and found path is at most weight times longer than the shortest one. Weight less than 1 is treated as 1
+func WeightedAStar[Node comparable](weight float32) Searcher[Node] {
+	return weightedAStar[Node]{weight: max(weight, 1)}
+}
+
+// BidirectionalAStar return searcher which runs A* from start and dest at once until searches meet
+// Backward search follows only edges which are linked both ways, so path over one-way links (off-mesh links)
+// is found by forward search and could be longer than the shortest one
+func BidirectionalAStar[Node comparable]() Searcher[Node] {
+	return bidirectionalAStar[Node]{}
+}
+
+// Dijkstra return searcher of the shortest path without heuristic
+func Dijkstra[Node comparable]() Searcher[Node] {
+	return dijkstra[Node]{}
+}
+
+// ThetaStar return any-angle searcher, node is linked with parent of its predecessor if there is line of sight
+// between them, so path isn't bound to graph edges. Line of sight is walkable area of query (graphs.SightChecker),
+// graphs without such check use raycasts
+func ThetaStar[Node comparable]() Searcher[Node] {
+	return thetaStar[Node]{}
+}
+
+func (aStar[Node]) Search(q *SearchQuery[Node]) []Node {
+	return q.buffers().findPath(q.Graph, q.Start, q.Dest, q.Hash, q.Cost, q.Heuristic, nil)
+}
+
+func (s weightedAStar[Node]) Search(q *SearchQuery[Node]) []Node {
+	heuristic := func(a, b Node) float32 {
+		return s.weight * q.Heuristic(a, b)
+	}
+
+	return q.buffers().findPath(q.Graph, q.Start, q.Dest, q.Hash, q.Cost, heuristic, nil)
+}
+
+func (bidirectionalAStar[Node]) Search(q *SearchQuery[Node]) []Node {
+	return q.buffers().findPathBidirectional(q.Graph, q.Start, q.Dest, q.Hash, q.Cost, q.Heuristic)
+}
+
+func (dijkstra[Node]) Search(q *SearchQuery[Node]) []Node {
+	return q.buffers().findPath(q.Graph, q.Start, q.Dest, q.Hash, q.Cost, zeroHeuristic, nil)
+}
+
+func (thetaStar[Node]) Search(q *SearchQuery[Node]) []Node {
+	return q.buffers().findPath(q.Graph, q.Start, q.Dest, q.Hash, q.Cost, q.Heuristic, q.LineOfSight)
+}
+
+func zeroHeuristic[Node comparable](_, _ Node) float32 {
+	return 0
+}